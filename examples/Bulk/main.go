@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"iter"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := rapididentity.Options{
+		HTTPClient:      &http.Client{},
+		BaseUrl:         baseUrl,
+		ServiceIdentity: os.Getenv("RI_KEY"),
+		RateLimit: &rapididentity.RateLimitOptions{
+			Default: rapididentity.RateLimit{Rate: 20, Burst: 10},
+		},
+	}
+
+	client, err := rapididentity.New(options)
+	if err != nil {
+		riError, ok := err.(rapididentity.RapidIdentityError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	// Reads one idautoID per line from the
+	// file named by the first argument.
+	file, err := os.Open(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	var inputs iter.Seq[rapididentity.GetUserByIdInput] = func(yield func(rapididentity.GetUserByIdInput) bool) {
+		for scanner.Scan() {
+			if !yield(rapididentity.GetUserByIdInput{Id: scanner.Text()}) {
+				return
+			}
+		}
+	}
+
+	ctx := context.Background()
+	results, summary := rapididentity.BulkSeq(ctx, inputs, 10, client.GetUserById)
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Printf("%s: %s\n", result.Input.Id, result.Err)
+			continue
+		}
+		fmt.Printf("%s: %s\n", result.Input.Id, result.Output.Username)
+	}
+	fmt.Printf("%d succeeded, %d failed, %d canceled of %d in %s\n",
+		summary.Succeeded, summary.Failed, summary.Canceled, summary.Total, summary.Duration)
+}
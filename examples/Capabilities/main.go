@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := rapididentity.Options{
+		HTTPClient:      &http.Client{},
+		BaseUrl:         baseUrl,
+		ServiceIdentity: os.Getenv("RI_KEY"),
+		Capabilities: &rapididentity.CapabilityOptions{
+			// Gates a custom request on the Requests module.
+			Operations: map[string]rapididentity.OperationRequirement{
+				"GET /admin/workflow/resources": {Module: "workflow"},
+			},
+		},
+	}
+
+	client, err := rapididentity.New(options)
+	if err != nil {
+		riError, ok := err.(rapididentity.RapidIdentityError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+
+	input := rapididentity.RunAuditReportInput{
+		Query: rapididentity.AuditReportQuery{
+			FieldName:    "action",
+			FieldValue:   "login",
+			OperatorType: rapididentity.EQUAL,
+		},
+	}
+
+	ctx := context.Background()
+	output, err := client.RunAuditReport(ctx, input)
+	var unsupported *rapididentity.UnsupportedError
+	if errors.As(err, &unsupported) {
+		log.Fatalf("skipping the audit report: %s", unsupported)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%+v\n", output)
+}
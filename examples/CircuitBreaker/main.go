@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	breaker := rapididentity.NewCircuitBreaker(rapididentity.CircuitBreakerOptions{
+		FailureThreshold: 5,
+		OpenTimeout:      time.Minute,
+		OnStateChange: func(status rapididentity.CircuitStatus, from rapididentity.CircuitState) {
+			log.Printf("%s %s: %s -> %s", status.BaseUrl, status.Operation, from, status.State)
+		},
+	})
+
+	// RI_TENANTS lists the tenants as name=url pairs separated
+	// by commas, sharing the RI_KEY service identity and the
+	// circuit breaker.
+	tenants := map[string]rapididentity.Options{}
+	for _, tenant := range strings.Split(os.Getenv("RI_TENANTS"), ",") {
+		name, rawUrl, _ := strings.Cut(tenant, "=")
+		baseUrl, err := url.Parse(rawUrl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tenants[name] = rapididentity.Options{
+			HTTPClient:      &http.Client{Timeout: 30 * time.Second},
+			BaseUrl:         baseUrl,
+			ServiceIdentity: os.Getenv("RI_KEY"),
+			CircuitBreaker:  breaker,
+		}
+	}
+
+	pool := rapididentity.NewTenantPool(tenants)
+	defer pool.Close()
+
+	// Reports the circuits of every tenant.
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		circuits := breaker.Circuits()
+		for _, circuit := range circuits {
+			if circuit.State == rapididentity.CircuitOpen {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				break
+			}
+		}
+		json.NewEncoder(w).Encode(circuits)
+	})
+	go func() {
+		log.Fatal(http.ListenAndServe(":8080", nil))
+	}()
+
+	ctx := context.Background()
+	for {
+		results := rapididentity.FanOut(ctx, pool, 4,
+			func(ctx context.Context, tenant string, client *rapididentity.Client) (*rapididentity.GetConnectJobsOutput, error) {
+				return client.GetConnectJobs(ctx, rapididentity.GetConnectJobsInput{})
+			})
+
+		for _, result := range results {
+			var openErr *rapididentity.CircuitOpenError
+			switch {
+			case errors.As(result.Err, &openErr):
+				log.Printf("%s: skipped until %s", result.Tenant, openErr.RetryAt.Format(time.TimeOnly))
+			case result.Err != nil:
+				log.Printf("%s: %s", result.Tenant, result.Err)
+			default:
+				log.Printf("%s: %d jobs", result.Tenant, len(result.Output.Jobs))
+			}
+		}
+		time.Sleep(10 * time.Second)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+type WorkflowResource struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := rapididentity.Options{
+		HTTPClient:      &http.Client{},
+		BaseUrl:         baseUrl,
+		ServiceIdentity: os.Getenv("RI_KEY"),
+	}
+
+	client, err := rapididentity.New(options)
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	resources, err := rapididentity.Do[[]WorkflowResource](ctx, client, "GET", "admin/workflow/resources", nil)
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+
+	for _, resource := range *resources {
+		fmt.Printf("%s: %s\n", resource.Id, resource.Name)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := rapididentity.Options{
+		HTTPClient:      &http.Client{},
+		BaseUrl:         baseUrl,
+		ServiceIdentity: os.Getenv("RI_KEY"),
+		DryRun:          true,
+	}
+
+	client, err := rapididentity.New(options)
+	if err != nil {
+		riError, ok := err.(rapididentity.RapidIdentityError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// Read operations are sent in dry-run mode.
+	found, err := client.GetConnectActionById(ctx, rapididentity.GetConnectActionByIdInput{
+		Id: "Test",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	action := found.Action
+	action.Description = "Updated description"
+	_, err = client.SaveConnectAction(ctx, rapididentity.SaveConnectActionInput{
+		Action: action,
+	})
+	var dryRun *rapididentity.DryRunError
+	if !errors.As(err, &dryRun) {
+		log.Fatalf("expected a dry run, got %v", err)
+	}
+
+	fmt.Printf("%s %s\n%s\n", dryRun.Plan.Method, dryRun.Plan.Url, dryRun.Plan.Body)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	// RI_TENANTS lists the tenants as name=url pairs separated
+	// by commas, sharing the RI_KEY service identity.
+	tenants := map[string]rapididentity.Options{}
+	for _, tenant := range strings.Split(os.Getenv("RI_TENANTS"), ",") {
+		name, rawUrl, _ := strings.Cut(tenant, "=")
+		baseUrl, err := url.Parse(rawUrl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tenants[name] = rapididentity.Options{
+			BaseUrl:         baseUrl,
+			ServiceIdentity: os.Getenv("RI_KEY"),
+		}
+	}
+
+	pool := rapididentity.NewTenantPool(tenants)
+	defer pool.Close()
+
+	ctx := context.Background()
+	results := rapididentity.FanOut(ctx, pool, 4,
+		func(ctx context.Context, tenant string, client *rapididentity.Client) (*rapididentity.GetConnectJobsOutput, error) {
+			return client.GetConnectJobs(ctx, rapididentity.GetConnectJobsInput{})
+		})
+
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Printf("%s: %s\n", result.Tenant, result.Err)
+			continue
+		}
+		fmt.Printf("%s: %d jobs\n", result.Tenant, len(result.Output.Jobs))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+// Prompts for the one time code of totp, email and sms steps.
+func promptCode(ctx context.Context, challenge rapididentity.Challenge) (map[string]any, error) {
+	switch challenge.Type {
+	case "totp", "email", "sms":
+		fmt.Printf("%s code: ", challenge.Type)
+		code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"code": strings.TrimSpace(code)}, nil
+	}
+	return nil, fmt.Errorf("unsupported authentication method %s", challenge.Type)
+}
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := rapididentity.Options{
+		HTTPClient: &http.Client{},
+		BaseUrl:    baseUrl,
+	}
+
+	ctx := context.Background()
+	client, err := rapididentity.Login(ctx, options, rapididentity.LoginInput{
+		Username:  os.Getenv("RI_USER"),
+		Responder: rapididentity.PasswordResponder(os.Getenv("RI_PWD"), promptCode),
+	})
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	output, err := client.GetBootstrapInfo(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%+v\n", output)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+// Reads RI_URL and RI_KEY or RI_USER and RI_PWD, falling
+// back to the RI_PROFILE profile in ~/.rapididentity/config.
+func main() {
+	client, err := rapididentity.NewFromEnvironment(rapididentity.Options{})
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	output, err := client.GetBootstrapInfo(ctx)
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%+v\n", output)
+}
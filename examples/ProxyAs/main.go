@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := rapididentity.Options{
+		HTTPClient: &http.Client{},
+		BaseUrl:    baseUrl,
+		RapidIdentityUser: &rapididentity.RapidIdentityUser{
+			Username: os.Getenv("RI_USER"),
+			Password: os.Getenv("RI_PWD"),
+		},
+	}
+
+	client, err := rapididentity.New(options)
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	userId := "08b5f0ec-d56a-4712-ada5-c86074ab11db"
+	proxy, err := client.ProxyAs(ctx, rapididentity.ProxyAsInput{UserId: userId})
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+	defer proxy.Close()
+
+	output, err := proxy.GetDelegationsForUser(ctx, rapididentity.GetDelegationsForUserInput{UserId: userId})
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%+v\n", output)
+}
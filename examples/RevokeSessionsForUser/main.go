@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := rapididentity.Options{
+		HTTPClient:      &http.Client{},
+		BaseUrl:         baseUrl,
+		ServiceIdentity: os.Getenv("RI_KEY"),
+	}
+
+	client, err := rapididentity.New(options)
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	userId := "08b5f0ec-d56a-4712-ada5-c86074ab11db"
+	output, err := client.ListSessionsForUser(ctx, rapididentity.ListSessionsForUserInput{UserId: userId})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, session := range output.Sessions {
+		fmt.Printf("%s last used %s from %s\n", session.Id, session.LastUsed, session.LastUsedClientIp)
+	}
+
+	err = client.RevokeSessionsForUser(ctx, rapididentity.RevokeSessionsForUserInput{UserId: userId})
+	if err != nil {
+		var riError rapididentity.RapidIdentityError
+		ok := errors.As(err, &riError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+}
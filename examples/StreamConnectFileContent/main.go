@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := rapididentity.Options{
+		HTTPClient:      &http.Client{},
+		BaseUrl:         baseUrl,
+		ServiceIdentity: os.Getenv("RI_KEY"),
+	}
+
+	client, err := rapididentity.New(options)
+	if err != nil {
+		riError, ok := err.(rapididentity.RapidIdentityError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+
+	// Resume a partial download by appending to the file
+	// from its current size.
+	file, err := os.OpenFile("job.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	input := rapididentity.StreamConnectFileContentInput{
+		GetConnectFileContentInput: rapididentity.GetConnectFileContentInput{
+			Path: "log/job/Test.log",
+		},
+		Offset: info.Size(),
+		Progress: func(transferred int64, total int64) {
+			fmt.Printf("\r%d of %d bytes", transferred, total)
+		},
+	}
+
+	ctx := context.Background()
+	stream, err := client.StreamConnectFileContent(ctx, input)
+	if err != nil {
+		riError, ok := err.(rapididentity.RapidIdentityError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+	defer stream.Close()
+
+	if _, err := io.Copy(file, stream); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println()
+}
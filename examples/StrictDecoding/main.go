@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+func main() {
+	baseUrl, err := url.Parse(os.Getenv("RI_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var drift rapididentity.DriftCollector
+	options := rapididentity.Options{
+		HTTPClient:      &http.Client{},
+		BaseUrl:         baseUrl,
+		ServiceIdentity: os.Getenv("RI_KEY"),
+		StrictDecoding: &rapididentity.StrictDecodingOptions{
+			OnDrift: drift.Record,
+		},
+	}
+
+	client, err := rapididentity.New(options)
+	if err != nil {
+		riError, ok := err.(rapididentity.RapidIdentityError)
+		if ok {
+			log.Fatalf("Request URL: %s, Status Code: %d, Message: %s", riError.ReqUrl, riError.Code, riError.Message)
+		}
+		log.Fatal(err)
+	}
+
+	// Calls a few read operations after an upgrade
+	// and reports how their responses changed.
+	ctx := context.Background()
+	if _, err := client.GetBootstrapInfo(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := client.GetConnectJobs(ctx, rapididentity.GetConnectJobsInput{}); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := client.GetConnectActions(ctx, rapididentity.GetConnectActionsInput{}); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, report := range drift.Reports() {
+		fmt.Printf("%s (%s)\n", report.Operation, report.OutputType)
+		for _, field := range report.Extra {
+			fmt.Printf("  + %s\n", field)
+		}
+		for _, field := range report.Missing {
+			fmt.Printf("  - %s\n", field)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package rapididentity
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// The Connect methods of the Client.
+type ConnectApi interface {
+	GetConnectActions(ctx context.Context, params GetConnectActionsInput) (*GetConnectActionsOutput, error)
+	GetConnectActionById(ctx context.Context, params GetConnectActionByIdInput) (*GetConnectActionByIdOutput, error)
+	GetConnectFileContent(ctx context.Context, params GetConnectFileContentInput) ([]byte, error)
+	GetConnectFileContentZip(ctx context.Context, params GetConnectFileContentZipInput) ([]byte, error)
+	StreamConnectFileContent(ctx context.Context, params StreamConnectFileContentInput) (*FileStream, error)
+	StreamConnectFileContentZip(ctx context.Context, params StreamConnectFileContentZipInput) (*FileStream, error)
+	GetConnectFiles(ctx context.Context, params GetConnectFilesInput) (*GetConnectFilesOutput, error)
+	GetConnectJobs(ctx context.Context, params GetConnectJobsInput) (*GetConnectJobsOutput, error)
+	GetConnectProjects(ctx context.Context) (*GetConnectProjectsOutput, error)
+	SearchConnectActionSets(ctx context.Context, params SearchConnectActionSetsInput) (*SearchConnectActionSetsOutput, error)
+	SaveConnectAction(ctx context.Context, params SaveConnectActionInput) (*SaveConnectActionOutput, error)
+	RunConnectAction(ctx context.Context, params RunConnectActionInput) (*RunConnectActionOutput, error)
+	DeleteConnectActionById(ctx context.Context, params DeleteConnectActionByIdInput) (*DeleteConnectActionByIdOutput, error)
+}
+
+// The People methods of the Client.
+type PeopleApi interface {
+	GetDelegationsForUser(ctx context.Context, params GetDelegationsForUserInput) (*GetDelegationsForUserOutput, error)
+	GetUserById(ctx context.Context, params GetUserByIdInput) (*User, error)
+	RunUserQuery(ctx context.Context, params RunUserQueryInput) (UserList, error)
+	SetPassword(ctx context.Context, params SetPasswordInput) (SetPasswordOutput, error)
+	GetPasswordPoliciesFor(ctx context.Context, params GetPasswordPoliciesForInput) (*PasswordPolicy, error)
+}
+
+// The Reports methods of the Client.
+type ReportsApi interface {
+	RunAuditReport(ctx context.Context, params RunAuditReportInput) (*RunAuditReportOutput, error)
+}
+
+// The Configuration methods of the Client.
+type ConfigurationApi interface {
+	GetAuthenticationPoliciesForUser(ctx context.Context, params GetAuthenticationPoliciesForUserInput) (*GetAuthenticationPoliciesForUserOutput, error)
+	GetBootstrapInfo(ctx context.Context) (*GetBootstrapInfoOutput, error)
+	GetRapidIdentityAttributes(ctx context.Context) (StringList, error)
+}
+
+// The session management methods of the Client.
+type SessionsApi interface {
+	Session() *Session
+	GetCurrentSession(ctx context.Context) (*Session, error)
+	ListSessionsForUser(ctx context.Context, params ListSessionsForUserInput) (*ListSessionsForUserOutput, error)
+	RevokeSession(ctx context.Context, params RevokeSessionInput) error
+	RevokeSessionsForUser(ctx context.Context, params RevokeSessionsForUserInput) error
+}
+
+// The methods of the Client, so that code using the Client
+// can be tested with a mock such as rapididentitytest.Mock.
+// Depend on the narrowest interface that is needed, for
+// example ConnectApi.
+//
+// ProxyAs is not included since it returns a *Client; pass
+// the proxied client as an Api instead. GenerateRequest and
+// ReceiveResponse are building blocks of the Client itself.
+//
+//	type Syncer struct {
+//		RapidIdentity rapididentity.ConnectApi
+//	}
+type Api interface {
+	ConnectApi
+	PeopleApi
+	ReportsApi
+	ConfigurationApi
+	SessionsApi
+
+	DoCustomRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error)
+	DoCustomRequestWithHeaders(ctx context.Context, method string, path string, headers http.Header, body io.Reader) (*http.Response, error)
+	InvalidateCache(operations ...string) error
+	IsProxy() bool
+	Close() error
+	CloseContext(ctx context.Context) error
+}
+
+var _ Api = (*Client)(nil)
@@ -0,0 +1,26 @@
+package rapididentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Fails when an exported Client method is neither part
+// of Api nor deliberately left out of it.
+func TestApiCoversClient(t *testing.T) {
+	t.Parallel()
+	excluded := map[string]bool{
+		"ProxyAs":         true,
+		"GenerateRequest": true,
+		"ReceiveResponse": true,
+	}
+
+	api := reflect.TypeFor[Api]()
+	client := reflect.TypeFor[*Client]()
+	for i := range client.NumMethod() {
+		name := client.Method(i).Name
+		if _, ok := api.MethodByName(name); !ok && !excluded[name] {
+			t.Errorf("Client.%s is missing from Api", name)
+		}
+	}
+}
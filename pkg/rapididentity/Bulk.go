@@ -0,0 +1,130 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+)
+
+// The default number of inputs run
+// at once by Bulk and BulkSeq.
+const DefaultBulkConcurrency = 8
+
+// The result of the function run by Bulk for an input.
+type BulkResult[In any, Out any] struct {
+	// The position of the input.
+	Index int
+
+	// The input passed to the function.
+	Input In
+
+	// The output of the function.
+	Output Out
+
+	// The error of the function, or the context
+	// error if the input was never started.
+	Err error
+}
+
+// Counts of the results of Bulk.
+type BulkSummary struct {
+	// The number of inputs.
+	Total int
+
+	// The number of results without an error.
+	Succeeded int
+
+	// The number of results with an error other
+	// than context.Canceled or context.DeadlineExceeded.
+	Failed int
+
+	// The number of results that were not started or
+	// ended with context.Canceled or context.DeadlineExceeded.
+	Canceled int
+
+	// How long the run took.
+	Duration time.Duration
+}
+
+// Runs the function, usually a Client method, for every
+// input with at most concurrency inputs at once, and returns
+// the results in the order of the inputs with a summary. A
+// concurrency of 0 or less uses DefaultBulkConcurrency.
+// Once ctx is done, inputs that have not started get the
+// context error.
+//
+// Every call goes through the Client, so its rate limits,
+// retry policy and circuit breaker apply. Set the concurrency
+// to about the rate limit burst; more goroutines only wait
+// for the rate limiter.
+//
+//	results, summary := rapididentity.Bulk(ctx, inputs, 16, client.GetUserById)
+//	for _, result := range results {
+//		if result.Err != nil {
+//			log.Printf("%s: %s", result.Input.Id, result.Err)
+//		}
+//	}
+//	log.Printf("%d of %d users", summary.Succeeded, summary.Total)
+func Bulk[In any, Out any](ctx context.Context, inputs []In, concurrency int, fn func(ctx context.Context, input In) (Out, error)) ([]BulkResult[In, Out], BulkSummary) {
+	return BulkSeq(ctx, slices.Values(inputs), concurrency, fn)
+}
+
+// Like Bulk for the inputs of an iterator, which is read
+// as inputs are started so that it may for example read
+// the ids from a file. Once ctx is done the iterator is
+// still read to the end, and the remaining inputs get the
+// context error.
+func BulkSeq[In any, Out any](ctx context.Context, inputs iter.Seq[In], concurrency int, fn func(ctx context.Context, input In) (Out, error)) ([]BulkResult[In, Out], BulkSummary) {
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	start := time.Now()
+	// Pointers since the slice grows while
+	// the goroutines write the results.
+	var pending []*BulkResult[In, Out]
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for input := range inputs {
+		result := &BulkResult[In, Out]{Index: len(pending), Input: input}
+		pending = append(pending, result)
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result.Output, result.Err = fn(ctx, result.Input)
+		}()
+	}
+	wg.Wait()
+
+	results := make([]BulkResult[In, Out], len(pending))
+	summary := BulkSummary{Total: len(pending)}
+	for i, result := range pending {
+		results[i] = *result
+		switch {
+		case result.Err == nil:
+			summary.Succeeded++
+		case errors.Is(result.Err, context.Canceled), errors.Is(result.Err, context.DeadlineExceeded):
+			summary.Canceled++
+		default:
+			summary.Failed++
+		}
+	}
+	summary.Duration = time.Since(start)
+
+	return results, summary
+}
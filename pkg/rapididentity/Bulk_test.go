@@ -0,0 +1,103 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulk(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	var inFlight, maxInFlight atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/admin/ldap/users/{dnOrId}", func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		id := r.PathValue("dnOrId")
+		if id == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"id": "%s"}`, id)
+	})
+
+	var inputs []GetUserByIdInput
+	for i := range 20 {
+		inputs = append(inputs, GetUserByIdInput{Id: fmt.Sprint(i)})
+	}
+	inputs[7].Id = "missing"
+
+	results, summary := Bulk(context.Background(), inputs, 3, client.GetUserById)
+	if got, want := len(results), len(inputs); got != want {
+		t.Fatalf("results: got %d, want %d", got, want)
+	}
+	for i, result := range results {
+		if result.Index != i || result.Input != inputs[i] {
+			t.Errorf("result %d: got index %d and input %+v", i, result.Index, result.Input)
+		}
+		if i == 7 {
+			if !errors.Is(result.Err, ErrNotFound) {
+				t.Errorf("result %d: got error %v, want ErrNotFound", i, result.Err)
+			}
+			continue
+		}
+		if result.Err != nil || result.Output.Id != inputs[i].Id {
+			t.Errorf("result %d: got %+v and error %v", i, result.Output, result.Err)
+		}
+	}
+
+	if summary.Total != 20 || summary.Succeeded != 19 || summary.Failed != 1 || summary.Canceled != 0 {
+		t.Errorf("summary: got %+v", summary)
+	}
+	if got := maxInFlight.Load(); got > 3 {
+		t.Errorf("concurrency: got %d requests at once, want at most 3", got)
+	}
+}
+
+func TestBulkSeqCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inputs := func(yield func(int) bool) {
+		for i := range 10 {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	results, summary := BulkSeq(ctx, inputs, 1, func(ctx context.Context, input int) (int, error) {
+		if input == 2 {
+			cancel()
+			return 0, ctx.Err()
+		}
+		return input * 2, nil
+	})
+
+	if got, want := len(results), 10; got != want {
+		t.Fatalf("results: got %d, want %d", got, want)
+	}
+	if results[1].Output != 2 || results[1].Err != nil {
+		t.Errorf("result 1: got %+v", results[1])
+	}
+	for _, result := range results[3:] {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("result %d: got error %v, want context.Canceled", result.Index, result.Err)
+		}
+	}
+	if summary.Succeeded != 2 || summary.Canceled != 8 || summary.Failed != 0 {
+		t.Errorf("summary: got %+v", summary)
+	}
+}
@@ -0,0 +1,348 @@
+package rapididentity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options for caching the responses of slow-changing
+// read operations. Only operations with a TTL are cached.
+//
+//	options.Cache = &rapididentity.CacheOptions{
+//		TTLs: rapididentity.DefaultCacheTTLs(),
+//	}
+type CacheOptions struct {
+	// The storage of the cached responses.
+	// The default is NewMemoryCacheStore().
+	Store CacheStore
+
+	// How long responses are fresh, keyed by the
+	// operation in the //meta:operation format.
+	// For example "GET /bootstrapInfo". Stale responses
+	// with an ETag are revalidated with If-None-Match.
+	TTLs map[string]time.Duration
+}
+
+// Returns the TTLs for the operations whose
+// data rarely changes.
+func DefaultCacheTTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		opGetBootstrapInfo.String():           10 * time.Minute,
+		opGetRapidIdentityAttributes.String(): time.Hour,
+		opGetConnectProjects.String():         10 * time.Minute,
+		opGetConnectActions.String():          5 * time.Minute,
+	}
+}
+
+// A cached response.
+type CacheEntry struct {
+	// The cache key of the response.
+	Key string `json:"key"`
+
+	// The response body.
+	Body []byte `json:"body"`
+
+	// The ETag of the response, if any.
+	ETag string `json:"etag,omitempty"`
+
+	// When the response becomes stale.
+	Expires time.Time `json:"expires"`
+}
+
+// Storage for cached responses. Keys start with the
+// operation in the //meta:operation format followed by
+// a space. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Returns the entry for the key.
+	// The bool is false if there is none.
+	Get(key string) (CacheEntry, bool, error)
+
+	// Stores the entry under entry.Key.
+	Set(entry CacheEntry) error
+
+	// Removes all entries whose key
+	// starts with the prefix.
+	DeletePrefix(prefix string) error
+}
+
+// Operations whose cached responses are invalidated
+// after the mutating operation succeeds.
+var cacheInvalidations = map[Operation][]Operation{
+	opSaveConnectAction:       {opGetConnectActions, opGetConnectActionById, opSearchConnectActionSets},
+	opDeleteConnectActionById: {opGetConnectActions, opGetConnectActionById, opSearchConnectActionSets},
+}
+
+type responseCache struct {
+	store  CacheStore
+	ttls   map[string]time.Duration
+	logger *slog.Logger
+}
+
+func newResponseCache(options CacheOptions, logger *slog.Logger) *responseCache {
+	store := options.Store
+	if store == nil {
+		store = NewMemoryCacheStore()
+	}
+	return &responseCache{
+		store:  store,
+		ttls:   options.TTLs,
+		logger: logger,
+	}
+}
+
+// Removes the cached responses of the operations, for
+// example after changing Connect actions outside of the
+// Client. Operations are given in the //meta:operation
+// format. With no operations the whole cache is cleared.
+func (c *Client) InvalidateCache(operations ...string) error {
+	if c.cache == nil {
+		return nil
+	}
+	if len(operations) == 0 {
+		return c.cache.store.DeletePrefix("")
+	}
+	var errs []error
+	for _, operation := range operations {
+		errs = append(errs, c.cache.store.DeletePrefix(operation+" "))
+	}
+	return errors.Join(errs...)
+}
+
+// Serves the call from the cache when the operation has
+// a TTL, and invalidates cached responses after mutating
+// operations. Cache storage errors are logged and ignored.
+func (rc *responseCache) handle(ctx context.Context, c *Client, call *Call) error {
+	ttl := rc.ttls[call.Operation.String()]
+	if ttl <= 0 || call.Output == nil || call.Request.Method != http.MethodGet {
+		err := c.exchange(ctx, call)
+		if err == nil {
+			rc.invalidate(ctx, call.Operation)
+		}
+		return err
+	}
+
+	key := rc.key(call.Operation, call.Request)
+	entry, found, err := rc.store.Get(key)
+	if err != nil {
+		rc.logError(ctx, "get", err)
+		found = false
+	}
+	if found && time.Now().Before(entry.Expires) {
+		call.Response = cachedResponse(call.Request, entry)
+		return decodeOutput(call, entry.Body)
+	}
+	if found && entry.ETag != "" {
+		call.Request.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	res, err := c.do(call.Operation, call.Request.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	call.Response = res
+
+	var resBody []byte
+	if found && res.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		resBody = entry.Body
+	} else {
+		resBody, err = c.ReceiveResponse(res)
+		if err != nil {
+			return err
+		}
+		entry = CacheEntry{Key: key, Body: resBody, ETag: res.Header.Get("ETag")}
+	}
+
+	err = decodeOutput(call, resBody)
+	if err != nil {
+		return err
+	}
+	c.detectDrift(ctx, call)
+
+	entry.Expires = time.Now().Add(ttl)
+	if err := rc.store.Set(entry); err != nil {
+		rc.logError(ctx, "set", err)
+	}
+
+	return nil
+}
+
+// Removes the cached responses invalidated by the operation.
+func (rc *responseCache) invalidate(ctx context.Context, op Operation) {
+	for _, invalidated := range cacheInvalidations[op] {
+		if err := rc.store.DeletePrefix(invalidated.String() + " "); err != nil {
+			rc.logError(ctx, "invalidate", err)
+		}
+	}
+}
+
+// Builds the cache key from the operation, the request
+// URL and a hash of the authorization so that responses
+// are never shared between users or tenants.
+func (rc *responseCache) key(op Operation, req *http.Request) string {
+	authorization := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return op.String() + " " + req.URL.String() + " " + hex.EncodeToString(authorization[:8])
+}
+
+func (rc *responseCache) logError(ctx context.Context, action string, err error) {
+	if rc.logger != nil {
+		rc.logger.LogAttrs(ctx, slog.LevelWarn, "rapididentity cache "+action+" failed",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// Returns the response served from the cache.
+func cachedResponse(req *http.Request, entry CacheEntry) *http.Response {
+	header := http.Header{}
+	if entry.ETag != "" {
+		header.Set("ETag", entry.ETag)
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// In-memory CacheStore.
+type MemoryCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+// Creates an empty in-memory CacheStore.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{entries: map[string]CacheEntry{}}
+}
+
+func (s *MemoryCacheStore) Get(key string) (CacheEntry, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[key]
+	return entry, ok, nil
+}
+
+func (s *MemoryCacheStore) Set(entry CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.Key] = entry
+	return nil
+}
+
+func (s *MemoryCacheStore) DeletePrefix(prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
+
+// CacheStore keeping one JSON file per entry in a
+// directory, so the cache is shared between runs of
+// a CLI tool. Files are only readable by the owner as
+// responses may contain personal data.
+type DiskCacheStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Creates a CacheStore in the directory,
+// creating the directory if needed.
+func NewDiskCacheStore(dir string) (*DiskCacheStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskCacheStore{dir: dir}, nil
+}
+
+func (s *DiskCacheStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *DiskCacheStore) Get(key string) (CacheEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return CacheEntry{}, false, err
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false, err
+	}
+	return entry, entry.Key == key, nil
+}
+
+func (s *DiskCacheStore) Set(entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(entry.Key)
+	temp, err := os.CreateTemp(s.dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+func (s *DiskCacheStore) DeletePrefix(prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var entry CacheEntry
+		if json.Unmarshal(data, &entry) != nil || strings.HasPrefix(entry.Key, prefix) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
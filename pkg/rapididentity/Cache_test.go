@@ -0,0 +1,249 @@
+package rapididentity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setupCache(t *testing.T, cache CacheOptions) (*Client, *http.ServeMux) {
+	t.Helper()
+	return setupWithOptions(t, Options{Cache: &cache})
+}
+
+func TestCacheTTL(t *testing.T) {
+	t.Parallel()
+	client, mux := setupCache(t, CacheOptions{TTLs: DefaultCacheTTLs()})
+
+	var requests atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"projects": [{"name": "sec_mgr"}]}`)
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/connect/jobs", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	ctx := context.Background()
+	for range 3 {
+		output, err := client.GetConnectProjects(ctx)
+		if err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+		if len(output.Projects) != 1 {
+			t.Errorf("projects: got %+v, want one", output.Projects)
+		}
+	}
+	if got, want := requests.Load(), int32(1); got != want {
+		t.Errorf("cached requests: got %d, want %d", got, want)
+	}
+
+	for range 2 {
+		if _, err := client.GetConnectJobs(ctx, GetConnectJobsInput{}); err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+	}
+	if got, want := requests.Load(), int32(3); got != want {
+		t.Errorf("uncached requests: got %d, want %d", got, want)
+	}
+}
+
+func TestCacheETagRevalidation(t *testing.T) {
+	t.Parallel()
+	client, mux := setupCache(t, CacheOptions{
+		TTLs: map[string]time.Duration{"GET /bootstrapInfo": time.Nanosecond},
+	})
+
+	var requests, notModified atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"tenantId": "tenant"}`)
+	})
+
+	ctx := context.Background()
+	for range 3 {
+		output, err := client.GetBootstrapInfo(ctx)
+		if err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+		if got, want := output.TenantId, "tenant"; got != want {
+			t.Errorf("tenant id: got %s, want %s", got, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := requests.Load(), int32(3); got != want {
+		t.Errorf("requests: got %d, want %d", got, want)
+	}
+	if got, want := notModified.Load(), int32(2); got != want {
+		t.Errorf("not modified responses: got %d, want %d", got, want)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	t.Parallel()
+	client, mux := setupCache(t, CacheOptions{TTLs: DefaultCacheTTLs()})
+
+	var requests atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/admin/connect/actions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{"id": "1234"}`)
+			return
+		}
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"actions": []}`)
+	})
+
+	ctx := context.Background()
+	input := GetConnectActionsInput{Project: "$builtin"}
+	getActions := func() {
+		t.Helper()
+		if _, err := client.GetConnectActions(ctx, input); err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+	}
+
+	getActions()
+	getActions()
+	if _, err := client.SaveConnectAction(ctx, SaveConnectActionInput{}); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	getActions()
+	if err := client.InvalidateCache("GET /admin/connect/actions"); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	getActions()
+
+	if got, want := requests.Load(), int32(3); got != want {
+		t.Errorf("requests: got %d, want %d", got, want)
+	}
+}
+
+func TestCacheSeparatesCredentials(t *testing.T) {
+	t.Parallel()
+	store := NewMemoryCacheStore()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"tenantId": "%s"}`, r.Header.Get("Authorization"))
+	})
+
+	baseUrl, _ := url.Parse(server.URL)
+	ctx := context.Background()
+	for _, key := range []string{"key-1", "key-2"} {
+		client, err := New(Options{
+			ServiceIdentity: key,
+			BaseUrl:         baseUrl,
+			Cache:           &CacheOptions{Store: store, TTLs: DefaultCacheTTLs()},
+		})
+		if err != nil {
+			t.Fatalf("error creating client: %s", err)
+		}
+		output, err := client.GetBootstrapInfo(ctx)
+		if err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+		if got, want := output.TenantId, "Bearer "+key; got != want {
+			t.Errorf("tenant id: got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestDiskCacheStore(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	store, err := NewDiskCacheStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expires := time.Now().Add(time.Hour).Round(0)
+	entries := []CacheEntry{
+		{Key: "GET /bootstrapInfo https://example.com/api/rest/bootstrapInfo abc", Body: []byte(`{}`), ETag: `"v1"`, Expires: expires},
+		{Key: "GET /admin/connect/actions https://example.com/api/rest/admin/connect/actions abc", Body: []byte(`{"actions": []}`), Expires: expires},
+	}
+	for _, entry := range entries {
+		if err := store.Set(entry); err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+	}
+
+	reopened, err := NewDiskCacheStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, found, err := reopened.Get(entries[0].Key)
+	if err != nil || !found {
+		t.Fatalf("got found %t and error %v, want entry", found, err)
+	}
+	if string(entry.Body) != `{}` || entry.ETag != `"v1"` || !entry.Expires.Equal(expires) {
+		t.Errorf("entry: got %+v, want %+v", entry, entries[0])
+	}
+
+	files, _ := os.ReadDir(dir)
+	for _, file := range files {
+		info, _ := file.Info()
+		if perm := info.Mode().Perm(); perm&0o077 != 0 {
+			t.Errorf("file %s permissions: got %o, want owner only", file.Name(), perm)
+		}
+	}
+
+	if err := reopened.DeletePrefix("GET /admin/connect/actions "); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if _, found, _ := reopened.Get(entries[1].Key); found {
+		t.Error("deleted entry found, want none")
+	}
+	if _, found, _ := reopened.Get(entries[0].Key); !found {
+		t.Error("other entry not found, want it kept")
+	}
+}
+
+func TestCacheWithDiskStore(t *testing.T) {
+	t.Parallel()
+	store, err := NewDiskCacheStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, mux := setupCache(t, CacheOptions{Store: store, TTLs: DefaultCacheTTLs()})
+
+	var requests atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/admin/ldap/schema/attributes", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `["idautoID", "givenName"]`)
+	})
+
+	ctx := context.Background()
+	for range 2 {
+		output, err := client.GetRapidIdentityAttributes(ctx)
+		if err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+		if got, want := len(output), 2; got != want {
+			t.Errorf("attributes: got %d, want %d", got, want)
+		}
+	}
+	if got, want := requests.Load(), int32(1); got != want {
+		t.Errorf("requests: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,285 @@
+package rapididentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultCapabilitiesTTL = time.Hour
+
+// The tenant does not support the operation, either
+// because its version is older than the minimum version
+// of the operation or because the module of the operation
+// is not licensed. Use errors.As with *UnsupportedError
+// for the details.
+var ErrUnsupported = errors.New("rapididentity: operation not supported by the tenant")
+
+// What an operation requires from the tenant.
+type OperationRequirement struct {
+	// The minimum RapidIdentity version, compared
+	// with VersionInfo.Version segment by segment,
+	// for example 2024.1. Empty for any version.
+	MinVersion string
+
+	// The module that must be licensed, named by its
+	// ModuleInfo JSON key such as profiles or reporting.
+	// Empty for no module.
+	Module string
+}
+
+// Options for checking operations against the version
+// and the licensed modules of the tenant before sending
+// them. The bootstrap info of the tenant is fetched with
+// GetBootstrapInfo on first use and cached.
+type CapabilityOptions struct {
+	// How long the bootstrap info is cached.
+	// The default is 1h.
+	TTL time.Duration
+
+	// Requirements keyed by the //meta:operation name,
+	// for example "GET /admin/connect/jobs", replacing
+	// those declared by the operation. Use it to gate
+	// custom requests, such as "GET /admin/workflow/resources",
+	// or operations whose minimum version is known for
+	// your tenants.
+	Operations map[string]OperationRequirement
+}
+
+// Returned instead of sending a request that the tenant
+// does not support. It matches ErrUnsupported.
+type UnsupportedError struct {
+	// The operation in the //meta:operation format.
+	Operation string
+
+	// The requirement that is not met.
+	Requirement OperationRequirement
+
+	// The version of the tenant.
+	Version string
+}
+
+func (e *UnsupportedError) Error() string {
+	if e.Requirement.Module != "" {
+		return fmt.Sprintf("rapididentity: %s requires the %s module, which is not licensed", e.Operation, e.Requirement.Module)
+	}
+	return fmt.Sprintf("rapididentity: %s requires RapidIdentity %s or later, the tenant runs %s", e.Operation, e.Requirement.MinVersion, e.Version)
+}
+
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// Checks operations against the cached bootstrap
+// info of the tenant.
+type capabilities struct {
+	ttl        time.Duration
+	operations map[string]OperationRequirement
+
+	// Guards info and fetching. It is not held
+	// while the bootstrap info is fetched.
+	mu        sync.Mutex
+	info      *tenantInfo
+	fetchedAt time.Time
+
+	// Closed when the fetch in flight completes.
+	// Nil when there is none.
+	fetching chan struct{}
+}
+
+// The parts of the bootstrap info the capabilities are
+// checked against. The licensed flags are pointers, unlike
+// ModuleLicenseInfo.Licensed, to tell modules without the
+// flag, which fall back to the legacy LicenseInfo.Modules.
+type tenantInfo struct {
+	VersionInfo struct {
+		Version string `json:"version"`
+	} `json:"versionInfo"`
+	ModuleInfo map[string]struct {
+		Licensed *bool `json:"licensed"`
+	} `json:"moduleInfo"`
+	LicenseInfo struct {
+		Modules StringList `json:"modules"`
+	} `json:"licenseInfo"`
+}
+
+func newCapabilities(options CapabilityOptions) *capabilities {
+	if options.TTL <= 0 {
+		options.TTL = defaultCapabilitiesTTL
+	}
+	return &capabilities{
+		ttl:        options.TTL,
+		operations: options.Operations,
+	}
+}
+
+// Returns the requirement of the operation.
+func (cp *capabilities) requirement(op Operation) OperationRequirement {
+	if requirement, ok := cp.operations[op.String()]; ok {
+		return requirement
+	}
+	return OperationRequirement{MinVersion: op.MinVersion, Module: op.Module}
+}
+
+// Returns an *UnsupportedError if the tenant does not
+// support the operation, fetching the bootstrap info
+// with the client when it is missing or expired. An
+// error fetching it is returned as is and not cached.
+func (cp *capabilities) check(ctx context.Context, c *Client, op Operation) error {
+	requirement := cp.requirement(op)
+	if requirement == (OperationRequirement{}) || op.Name == opGetBootstrapInfo.Name {
+		return nil
+	}
+
+	info, err := cp.tenantInfo(ctx, c)
+	if err != nil {
+		return fmt.Errorf("rapididentity: unable to check the capabilities of the tenant: %w", err)
+	}
+
+	version := info.VersionInfo.Version
+	if requirement.MinVersion != "" {
+		if cmp, ok := compareVersions(version, requirement.MinVersion); ok && cmp < 0 {
+			return &UnsupportedError{Operation: op.String(), Requirement: OperationRequirement{MinVersion: requirement.MinVersion}, Version: version}
+		}
+	}
+	if requirement.Module != "" {
+		if licensed, ok := moduleLicensed(info, requirement.Module); ok && !licensed {
+			return &UnsupportedError{Operation: op.String(), Requirement: OperationRequirement{Module: requirement.Module}, Version: version}
+		}
+	}
+	return nil
+}
+
+// Returns the cached bootstrap info, fetching it when it
+// is missing or expired. Concurrent callers wait for the
+// fetch in flight until their context is done, and fetch
+// again themselves if it fails.
+func (cp *capabilities) tenantInfo(ctx context.Context, c *Client) (*tenantInfo, error) {
+	for {
+		cp.mu.Lock()
+		if cp.info != nil && time.Since(cp.fetchedAt) < cp.ttl {
+			info := cp.info
+			cp.mu.Unlock()
+			return info, nil
+		}
+		if fetching := cp.fetching; fetching != nil {
+			cp.mu.Unlock()
+			select {
+			case <-fetching:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		fetching := make(chan struct{})
+		cp.fetching = fetching
+		cp.mu.Unlock()
+
+		info, err := fetchTenantInfo(ctx, c)
+
+		cp.mu.Lock()
+		if err == nil {
+			cp.info, cp.fetchedAt = info, time.Now()
+		}
+		cp.fetching = nil
+		close(fetching)
+		cp.mu.Unlock()
+		return info, err
+	}
+}
+
+// Fetches the bootstrap info of the tenant through the
+// pipeline of the client. The raw body is decoded here,
+// so strict decoding does not report the fields tenantInfo
+// leaves out.
+func fetchTenantInfo(ctx context.Context, c *Client) (*tenantInfo, error) {
+	endpointUrl := c.endpoint("/bootstrapInfo", nil)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resBody []byte
+	err = c.invoke(ctx, opGetBootstrapInfo, nil, req, &resBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var info tenantInfo
+	if err := json.Unmarshal(resBody, &info); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	return &info, nil
+}
+
+// Reports whether the module is licensed, and whether
+// the bootstrap info tells. Modules without a licensed
+// flag are looked up in the legacy LicenseInfo.Modules.
+func moduleLicensed(info *tenantInfo, module string) (bool, bool) {
+	if license, ok := info.ModuleInfo[module]; ok && license.Licensed != nil {
+		return *license.Licensed, true
+	}
+
+	if len(info.LicenseInfo.Modules) == 0 {
+		return false, false
+	}
+	for _, licensed := range info.LicenseInfo.Modules {
+		if strings.EqualFold(licensed, module) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// Compares two dotted versions such as 2024.1.2 by their
+// leading numeric segments, missing segments being zero.
+// Reports false if either version has no numeric segment.
+func compareVersions(a string, b string) (int, bool) {
+	as, bs := versionSegments(a), versionSegments(b)
+	if len(as) == 0 || len(bs) == 0 {
+		return 0, false
+	}
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// Returns the leading numeric segments of a version,
+// ignoring a v prefix and anything from the first
+// segment that is not a number, such as -SNAPSHOT.
+func versionSegments(version string) []int {
+	var segments []int
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".") {
+		digits := part
+		if i := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+			digits = part[:i]
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			break
+		}
+		segments = append(segments, n)
+		if len(digits) < len(part) {
+			break
+		}
+	}
+	return segments
+}
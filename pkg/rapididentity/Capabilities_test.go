@@ -0,0 +1,362 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setupCapabilities(t *testing.T, capabilities CapabilityOptions, bootstrapInfo string) (*Client, *http.ServeMux, *atomic.Int32) {
+	t.Helper()
+	client, mux := setupWithOptions(t, Options{Capabilities: &capabilities})
+	var fetches atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if bootstrapInfo == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, bootstrapInfo)
+	})
+
+	return client, mux, &fetches
+}
+
+const capabilitiesBootstrapInfo = `{
+	"versionInfo": {"version": "2023.2.1"},
+	"moduleInfo": {
+		"profiles": {"licensed": true},
+		"reporting": {"licensed": false},
+		"workflow": {"licensed": false}
+	}
+}`
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+	client, mux, fetches := setupCapabilities(t, CapabilityOptions{
+		Operations: map[string]OperationRequirement{
+			"GET /admin/connect/jobs":               {MinVersion: "2024.1"},
+			"GET /admin/connect/projects":           {MinVersion: "2023.2"},
+			"GET /admin/workflow/resources":         {Module: "workflow"},
+			"GET /profiles/aggregated/for/{userId}": {},
+		},
+	}, capabilitiesBootstrapInfo)
+	var sent atomic.Int32
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		sent.Add(1)
+		fmt.Fprint(w, `{}`)
+	})
+	ctx := context.Background()
+
+	_, err := client.RunAuditReport(ctx, RunAuditReportInput{})
+	var unsupported *UnsupportedError
+	if !errors.As(err, &unsupported) || !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("RunAuditReport: got error %v, want an *UnsupportedError", err)
+	}
+	if got, want := unsupported.Requirement.Module, "reporting"; got != want {
+		t.Errorf("module: got %s, want %s", got, want)
+	}
+	if !strings.Contains(err.Error(), "reporting module") {
+		t.Errorf("message: got %s", err)
+	}
+
+	_, err = client.GetConnectJobs(ctx, GetConnectJobsInput{})
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("GetConnectJobs: got error %v, want an *UnsupportedError", err)
+	}
+	if got, want := err.Error(), "rapididentity: GET /admin/connect/jobs requires RapidIdentity 2024.1 or later, the tenant runs 2023.2.1"; got != want {
+		t.Errorf("message: got %s, want %s", got, want)
+	}
+
+	_, err = client.DoCustomRequest(ctx, "GET", "admin/workflow/resources", nil)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("DoCustomRequest: got error %v, want ErrUnsupported", err)
+	}
+
+	if got, want := sent.Load(), int32(0); got != want {
+		t.Errorf("unsupported requests sent: got %d, want %d", got, want)
+	}
+
+	if _, err := client.GetConnectProjects(ctx); err != nil {
+		t.Errorf("GetConnectProjects: got error %s, want none", err)
+	}
+	if _, err := client.GetPasswordPoliciesFor(ctx, GetPasswordPoliciesForInput{}); err != nil {
+		t.Errorf("GetPasswordPoliciesFor: got error %s, want none", err)
+	}
+	if _, err := client.GetDelegationsForUser(ctx, GetDelegationsForUserInput{UserId: "08b5f0ec"}); err != nil {
+		t.Errorf("GetDelegationsForUser: got error %s, want none", err)
+	}
+	if got, want := sent.Load(), int32(3); got != want {
+		t.Errorf("supported requests sent: got %d, want %d", got, want)
+	}
+	if got, want := fetches.Load(), int32(1); got != want {
+		t.Errorf("bootstrap info fetches: got %d, want %d", got, want)
+	}
+}
+
+func TestCapabilitiesLegacyLicense(t *testing.T) {
+	t.Parallel()
+	client, mux, _ := setupCapabilities(t, CapabilityOptions{}, `{
+		"versionInfo": {"version": "2022.1.0"},
+		"moduleInfo": {
+			"profiles": {"myTabInfo": {"visible": true}},
+			"reporting": {},
+			"workflow": {"licensed": true}
+		},
+		"licenseInfo": {"modules": ["Profiles", "reporting"]}
+	}`)
+	var sent atomic.Int32
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		sent.Add(1)
+		fmt.Fprint(w, `{}`)
+	})
+	ctx := context.Background()
+
+	// The modules without a licensed flag are
+	// licensed through the legacy module list.
+	if _, err := client.GetPasswordPoliciesFor(ctx, GetPasswordPoliciesForInput{}); err != nil {
+		t.Errorf("GetPasswordPoliciesFor: got error %s, want none", err)
+	}
+	if _, err := client.RunAuditReport(ctx, RunAuditReportInput{}); err != nil {
+		t.Errorf("RunAuditReport: got error %s, want none", err)
+	}
+	if got, want := sent.Load(), int32(2); got != want {
+		t.Errorf("requests sent: got %d, want %d", got, want)
+	}
+
+	info := &tenantInfo{}
+	info.LicenseInfo.Modules = StringList{"profiles"}
+	if licensed, ok := moduleLicensed(info, "reporting"); !ok || licensed {
+		t.Errorf("reporting: got %t, %t, want false, true", licensed, ok)
+	}
+	if _, ok := moduleLicensed(&tenantInfo{}, "reporting"); ok {
+		t.Error("without license info: got known, want unknown")
+	}
+}
+
+// Every operation with a minimum version is refused by an
+// older tenant and sent to a tenant of that version.
+func TestCapabilitiesMinVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		op   Operation
+		call func(ctx context.Context, client *Client, options Options) error
+	}{
+		{opListSessionsForUser, func(ctx context.Context, client *Client, options Options) error {
+			_, err := client.ListSessionsForUser(ctx, ListSessionsForUserInput{UserId: "08b5f0ec"})
+			return err
+		}},
+		{opRevokeSession, func(ctx context.Context, client *Client, options Options) error {
+			return client.RevokeSession(ctx, RevokeSessionInput{SessionId: "1234"})
+		}},
+		{opRevokeSessionsForUser, func(ctx context.Context, client *Client, options Options) error {
+			return client.RevokeSessionsForUser(ctx, RevokeSessionsForUserInput{UserId: "08b5f0ec"})
+		}},
+		{opStartProxySession, func(ctx context.Context, client *Client, options Options) error {
+			_, err := client.ProxyAs(ctx, ProxyAsInput{UserId: "08b5f0ec"})
+			return err
+		}},
+		{opStartLogin, func(ctx context.Context, client *Client, options Options) error {
+			_, err := Login(ctx, options, LoginInput{Username: mockUsername, Responder: PasswordResponder(mockPassword, nil)})
+			return err
+		}},
+		{opGetAuthenticationPoliciesForUser, func(ctx context.Context, client *Client, options Options) error {
+			_, err := client.GetAuthenticationPoliciesForUser(ctx, GetAuthenticationPoliciesForUserInput{})
+			return err
+		}},
+		{opGetConnectFiles, func(ctx context.Context, client *Client, options Options) error {
+			_, err := client.GetConnectFiles(ctx, GetConnectFilesInput{Path: "scripts"})
+			return err
+		}},
+		{opGetConnectFileContent, func(ctx context.Context, client *Client, options Options) error {
+			_, err := client.GetConnectFileContent(ctx, GetConnectFileContentInput{Path: "scripts/sync.csv"})
+			return err
+		}},
+		{opGetConnectFileContentZip, func(ctx context.Context, client *Client, options Options) error {
+			_, err := client.GetConnectFileContentZip(ctx, GetConnectFileContentZipInput{PathList: StringList{"scripts"}})
+			return err
+		}},
+		{opStreamConnectFileContent, func(ctx context.Context, client *Client, options Options) error {
+			stream, err := client.StreamConnectFileContent(ctx, StreamConnectFileContentInput{GetConnectFileContentInput: GetConnectFileContentInput{Path: "scripts/sync.csv"}})
+			if err == nil {
+				stream.Close()
+			}
+			return err
+		}},
+		{opStreamConnectFileContentZip, func(ctx context.Context, client *Client, options Options) error {
+			stream, err := client.StreamConnectFileContentZip(ctx, StreamConnectFileContentZipInput{GetConnectFileContentZipInput: GetConnectFileContentZipInput{PathList: StringList{"scripts"}}})
+			if err == nil {
+				stream.Close()
+			}
+			return err
+		}},
+		{opSearchConnectActionSets, func(ctx context.Context, client *Client, options Options) error {
+			_, err := client.SearchConnectActionSets(ctx, SearchConnectActionSetsInput{SearchString: "Sync"})
+			return err
+		}},
+		{opGetConnectJobs, func(ctx context.Context, client *Client, options Options) error {
+			_, err := client.GetConnectJobs(ctx, GetConnectJobsInput{})
+			return err
+		}},
+	}
+
+	// Starts a tenant of the version, returning the options
+	// of a client for it and the number of requests sent
+	// other than bootstrap info.
+	tenant := func(version string) (Options, *atomic.Int32) {
+		var sent atomic.Int32
+		mux := http.NewServeMux()
+		mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"versionInfo": {"version": "%s"}}`, version)
+		})
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			sent.Add(1)
+			fmt.Fprint(w, `{}`)
+		})
+		return Options{
+			HTTPClient:      &http.Client{},
+			BaseUrl:         newTestServer(t, mux),
+			ServiceIdentity: mockServiceIdentity,
+			Capabilities:    &CapabilityOptions{},
+		}, &sent
+	}
+
+	ctx := context.Background()
+	for _, tc := range tests {
+		if tc.op.MinVersion == "" {
+			t.Errorf("%s: got no minimum version", tc.op.Name)
+			continue
+		}
+
+		options, sent := tenant("2019.4.0")
+		client, err := New(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tc.call(ctx, client, options)
+		var unsupported *UnsupportedError
+		if !errors.As(err, &unsupported) || unsupported.Requirement.MinVersion != tc.op.MinVersion {
+			t.Errorf("%s: got error %v, want an *UnsupportedError requiring %s", tc.op.Name, err, tc.op.MinVersion)
+		}
+		if got := sent.Load(); got != 0 {
+			t.Errorf("%s: requests sent to an older tenant: got %d, want 0", tc.op.Name, got)
+		}
+
+		options, sent = tenant(tc.op.MinVersion)
+		client, err = New(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tc.call(ctx, client, options); errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s: got error %v, want the request sent", tc.op.Name, err)
+		}
+		if got := sent.Load(); got == 0 {
+			t.Errorf("%s: got no request sent to a %s tenant", tc.op.Name, tc.op.MinVersion)
+		}
+	}
+
+	// The steps after the first are refused
+	// along with it on older tenants.
+	if opLoginStep.MinVersion != opStartLogin.MinVersion {
+		t.Errorf("login step: got minimum version %s, want %s", opLoginStep.MinVersion, opStartLogin.MinVersion)
+	}
+}
+
+func TestCapabilitiesConcurrentFetch(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var fetches atomic.Int32
+	client, mux := setupWithOptions(t, Options{Capabilities: &CapabilityOptions{}})
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		fmt.Fprint(w, capabilitiesBootstrapInfo)
+	})
+	mux.HandleFunc(baseUrlPath+"/reporting/auditQuery", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	})
+	ctx := context.Background()
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.RunAuditReport(ctx, RunAuditReportInput{})
+		first <- err
+	}()
+	<-started
+
+	// A caller waiting on the fetch in flight
+	// gives up when its own deadline passes.
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := client.RunAuditReport(timeout, RunAuditReportInput{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiting caller: got error %v, want context.DeadlineExceeded", err)
+	}
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := client.RunAuditReport(ctx, RunAuditReportInput{})
+		second <- err
+	}()
+	close(release)
+	for _, done := range []chan error{first, second} {
+		if err := <-done; !errors.Is(err, ErrUnsupported) {
+			t.Errorf("got error %v, want ErrUnsupported", err)
+		}
+	}
+	if got, want := fetches.Load(), int32(1); got != want {
+		t.Errorf("bootstrap info fetches: got %d, want %d", got, want)
+	}
+}
+
+func TestCapabilitiesFetchError(t *testing.T) {
+	t.Parallel()
+	client, _, fetches := setupCapabilities(t, CapabilityOptions{}, "")
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := client.RunAuditReport(ctx, RunAuditReportInput{})
+		if !errors.Is(err, ErrForbidden) || errors.Is(err, ErrUnsupported) {
+			t.Errorf("got error %v, want ErrForbidden", err)
+		}
+	}
+	if got, want := fetches.Load(), int32(2); got != want {
+		t.Errorf("bootstrap info fetches: got %d, want %d", got, want)
+	}
+
+	if _, err := client.GetBootstrapInfo(ctx); !errors.Is(err, ErrForbidden) {
+		t.Errorf("GetBootstrapInfo: got error %v, want ErrForbidden", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"2024.1.2", "2024.1", 1, true},
+		{"2024.1", "2024.1.0", 0, true},
+		{"2023.12", "2024.1", -1, true},
+		{"v2024.2", "2024.10", -1, true},
+		{"2024.1.0-SNAPSHOT", "2024.1", 0, true},
+		{"2024.3b2", "2024.3", 0, true},
+		{"unknown", "2024.1", 0, false},
+		{"", "2024.1", 0, false},
+	}
+	for _, tc := range tests {
+		got, ok := compareVersions(tc.a, tc.b)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("compareVersions(%q, %q): got %d, %t, want %d, %t", tc.a, tc.b, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package rapididentity
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenTimeout      = 30 * time.Second
+)
+
+// The request was not sent because the circuit of
+// the tenant and operation is open. Use errors.As
+// with *CircuitOpenError for the details.
+var ErrCircuitOpen = errors.New("rapididentity: circuit open")
+
+// The state of a circuit.
+type CircuitState int
+
+const (
+	// Requests are sent. Consecutive failures
+	// are counted until FailureThreshold.
+	CircuitClosed CircuitState = iota
+
+	// Requests fail fast with a *CircuitOpenError
+	// until OpenTimeout has passed.
+	CircuitOpen
+
+	// A single probe request is sent. Success closes
+	// the circuit, failure opens it again, and other
+	// requests fail fast in the meantime.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// Configurable options for a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// The number of consecutive failures that opens
+	// a closed circuit. A failure is a connection
+	// error or a 5xx response. The default is 5.
+	FailureThreshold int
+
+	// How long an open circuit fails fast before
+	// a probe request is allowed. The default is 30s.
+	OpenTimeout time.Duration
+
+	// Called after a circuit changes state. It is
+	// called synchronously, so it must not block.
+	OnStateChange func(status CircuitStatus, from CircuitState)
+}
+
+// The state of the circuit of a tenant and operation,
+// for example for a health endpoint.
+type CircuitStatus struct {
+	// The BaseUrl of the tenant.
+	BaseUrl string
+
+	// The operation in the //meta:operation format.
+	// For example "GET /admin/connect/actions".
+	Operation string
+
+	State CircuitState
+
+	// The number of consecutive failures.
+	Failures int
+
+	// When the circuit was last opened.
+	// Zero if it has never been opened.
+	OpenedAt time.Time
+}
+
+// Returned instead of sending a request while the
+// circuit is open. It matches ErrCircuitOpen.
+type CircuitOpenError struct {
+	// The status of the circuit when the
+	// request was rejected.
+	Status CircuitStatus
+
+	// When a probe request will be allowed. Zero
+	// while a probe of a half-open circuit is in
+	// flight.
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("rapididentity: circuit %s for %s %s", e.Status.State, e.Status.BaseUrl, e.Status.Operation)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// A circuit breaker keyed by the BaseUrl of the tenant
+// and the operation. When requests to a tenant keep
+// failing with connection errors or 5xx responses the
+// circuit opens and requests fail fast with ErrCircuitOpen
+// instead of each waiting for the http client timeout.
+// After OpenTimeout a probe request is sent, and the
+// circuit closes again if it succeeds.
+//
+// Set it with Options.CircuitBreaker. A CircuitBreaker
+// is safe for concurrent use, and can be shared by the
+// clients of several tenants, for example the Options
+// of a TenantPool, to report all of them with Circuits.
+//
+//	breaker := rapididentity.NewCircuitBreaker(rapididentity.CircuitBreakerOptions{})
+//	client, err := rapididentity.New(rapididentity.Options{
+//		BaseUrl:         baseUrl,
+//		ServiceIdentity: key,
+//		CircuitBreaker:  breaker,
+//	})
+type CircuitBreaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	onStateChange    func(status CircuitStatus, from CircuitState)
+
+	mu       sync.Mutex
+	circuits map[circuitKey]*circuit
+}
+
+type circuitKey struct {
+	baseUrl   string
+	operation string
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+
+	// Whether the probe of a half-open
+	// circuit is in flight.
+	probing bool
+}
+
+// Creates a CircuitBreaker with the
+// provided options.
+func NewCircuitBreaker(options CircuitBreakerOptions) *CircuitBreaker {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = defaultCircuitFailureThreshold
+	}
+	if options.OpenTimeout <= 0 {
+		options.OpenTimeout = defaultCircuitOpenTimeout
+	}
+	return &CircuitBreaker{
+		failureThreshold: options.FailureThreshold,
+		openTimeout:      options.OpenTimeout,
+		onStateChange:    options.OnStateChange,
+		circuits:         make(map[circuitKey]*circuit),
+	}
+}
+
+// Returns the state of the circuit of the tenant and
+// operation. The operation is in the //meta:operation
+// format, for example "GET /admin/connect/actions".
+// Circuits without requests are closed.
+func (cb *CircuitBreaker) State(baseUrl string, operation string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if ct, ok := cb.circuits[circuitKey{baseUrl, operation}]; ok {
+		return cb.current(ct)
+	}
+	return CircuitClosed
+}
+
+// Returns the status of every circuit that has had
+// a request, sorted by BaseUrl and operation.
+func (cb *CircuitBreaker) Circuits() []CircuitStatus {
+	cb.mu.Lock()
+	statuses := make([]CircuitStatus, 0, len(cb.circuits))
+	for key, ct := range cb.circuits {
+		status := ct.status(key)
+		status.State = cb.current(ct)
+		statuses = append(statuses, status)
+	}
+	cb.mu.Unlock()
+
+	slices.SortFunc(statuses, func(a, b CircuitStatus) int {
+		return cmp.Or(strings.Compare(a.BaseUrl, b.BaseUrl), strings.Compare(a.Operation, b.Operation))
+	})
+	return statuses
+}
+
+// Returns the state of the circuit, reporting an open
+// circuit whose timeout has passed as half-open.
+func (cb *CircuitBreaker) current(ct *circuit) CircuitState {
+	if ct.state == CircuitOpen && time.Since(ct.openedAt) >= cb.openTimeout {
+		return CircuitHalfOpen
+	}
+	return ct.state
+}
+
+func (ct *circuit) status(key circuitKey) CircuitStatus {
+	return CircuitStatus{
+		BaseUrl:   key.baseUrl,
+		Operation: key.operation,
+		State:     ct.state,
+		Failures:  ct.failures,
+		OpenedAt:  ct.openedAt,
+	}
+}
+
+// Reports whether a request for the operation may be
+// sent, turning an open circuit whose timeout has passed
+// into a half-open circuit with this request as its probe.
+// Returns whether the request is the probe.
+func (cb *CircuitBreaker) allow(key circuitKey) (bool, error) {
+	cb.mu.Lock()
+	ct, ok := cb.circuits[key]
+	if !ok {
+		ct = &circuit{}
+		cb.circuits[key] = ct
+	}
+
+	var changed *CircuitStatus
+	switch ct.state {
+	case CircuitOpen:
+		retryAt := ct.openedAt.Add(cb.openTimeout)
+		if time.Now().Before(retryAt) {
+			err := &CircuitOpenError{Status: ct.status(key), RetryAt: retryAt}
+			cb.mu.Unlock()
+			return false, err
+		}
+		ct.state = CircuitHalfOpen
+		ct.probing = true
+		status := ct.status(key)
+		changed = &status
+	case CircuitHalfOpen:
+		if ct.probing {
+			err := &CircuitOpenError{Status: ct.status(key)}
+			cb.mu.Unlock()
+			return false, err
+		}
+		ct.probing = true
+	}
+	probe := ct.probing
+	cb.mu.Unlock()
+
+	if changed != nil && cb.onStateChange != nil {
+		cb.onStateChange(*changed, CircuitOpen)
+	}
+	return probe, nil
+}
+
+// Records the outcome of a request allowed by allow. A
+// failure is a transport error or a 5xx response, while
+// other errors, such as rate limiter waits, closed clients
+// and ended contexts, count for nothing. Failures of
+// requests that were in flight when the circuit opened do
+// not extend the open timeout. Returns the new status and
+// the previous state.
+func (cb *CircuitBreaker) record(key circuitKey, probe bool, code int, err error) (CircuitStatus, CircuitState) {
+	cb.mu.Lock()
+	ct := cb.circuits[key]
+	from := ct.state
+	if probe {
+		ct.probing = false
+	}
+
+	switch {
+	case circuitFailure(code, err):
+		ct.failures++
+		if ct.state == CircuitHalfOpen || (ct.state == CircuitClosed && ct.failures >= cb.failureThreshold) {
+			ct.state = CircuitOpen
+			ct.openedAt = time.Now()
+		}
+	case err == nil:
+		ct.failures = 0
+		ct.state = CircuitClosed
+		ct.probing = false
+	}
+	status := ct.status(key)
+	cb.mu.Unlock()
+
+	if status.State != from && cb.onStateChange != nil {
+		cb.onStateChange(status, from)
+	}
+	return status, from
+}
+
+// Reports whether the outcome of a request is a failure
+// of the tenant: a 5xx response, or an error sending the
+// request that its context did not cause.
+func circuitFailure(code int, err error) bool {
+	if code >= 500 {
+		return true
+	}
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var riError RapidIdentityError
+	return errors.As(err, &riError) && riError.Code >= 500
+}
+
+// Sends a single attempt of the request unless the
+// circuit of the tenant and operation is open, and
+// records its outcome.
+func (c *Client) sendWithCircuitBreaker(op Operation, req *http.Request) (*http.Response, error) {
+	key := circuitKey{
+		baseUrl:   strings.TrimSuffix(c.baseEndpoint, "/api/rest"),
+		operation: op.String(),
+	}
+	probe, err := c.circuitBreaker.allow(key)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.attempt(op, req)
+	code := 0
+	if res != nil {
+		code = res.StatusCode
+	}
+	status, from := c.circuitBreaker.record(key, probe, code, err)
+
+	if c.logger != nil && status.State != from {
+		level := slog.LevelInfo
+		if status.State == CircuitOpen {
+			level = slog.LevelWarn
+		}
+		c.logger.LogAttrs(req.Context(), level, "rapididentity circuit "+status.State.String(),
+			slog.String("operation", op.Name),
+			slog.String("baseUrl", status.BaseUrl),
+			slog.Int("failures", status.Failures),
+		)
+	}
+	return res, err
+}
@@ -0,0 +1,231 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setupCircuitBreaker(t *testing.T, breaker *CircuitBreaker, policy *RetryPolicy) (*Client, *http.ServeMux) {
+	t.Helper()
+	return setupWithOptions(t, Options{
+		RetryPolicy:    policy,
+		CircuitBreaker: breaker,
+	})
+}
+
+// Handles the Connect projects endpoint, failing
+// with 503 unless healthy is set.
+func handleProjects(mux *http.ServeMux, healthy *atomic.Bool, requests *atomic.Int32) {
+	mux.HandleFunc(baseUrlPath+"/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"projects": [{"name": "sec_mgr"}]}`)
+	})
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var transitions []string
+	breaker := NewCircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 3,
+		OpenTimeout:      50 * time.Millisecond,
+		OnStateChange: func(status CircuitStatus, from CircuitState) {
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, from.String()+"->"+status.State.String())
+		},
+	})
+	client, mux := setupCircuitBreaker(t, breaker, nil)
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	handleProjects(mux, &healthy, &requests)
+	ctx := context.Background()
+	operation := opGetConnectProjects.String()
+
+	for range 3 {
+		if _, err := client.GetConnectProjects(ctx); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("got error %v before the threshold", err)
+		}
+	}
+	_, err := client.GetConnectProjects(ctx)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got error %v, want a *CircuitOpenError", err)
+	}
+	if openErr.RetryAt.IsZero() || openErr.Status.Operation != operation {
+		t.Errorf("got %+v", openErr)
+	}
+	if got, want := requests.Load(), int32(3); got != want {
+		t.Errorf("requests: got %d, want %d", got, want)
+	}
+
+	circuits := breaker.Circuits()
+	if len(circuits) != 1 || circuits[0].State != CircuitOpen || circuits[0].Failures != 3 {
+		t.Fatalf("circuits: got %+v", circuits)
+	}
+	if got, want := circuits[0].BaseUrl, strings.TrimSuffix(client.baseEndpoint, baseUrlPath); got != want {
+		t.Errorf("base url: got %s, want %s", got, want)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got, want := breaker.State(circuits[0].BaseUrl, operation), CircuitHalfOpen; got != want {
+		t.Errorf("state after the timeout: got %s, want %s", got, want)
+	}
+
+	healthy.Store(true)
+	if _, err := client.GetConnectProjects(ctx); err != nil {
+		t.Fatalf("probe: got error %s, want none", err)
+	}
+	if got, want := breaker.State(circuits[0].BaseUrl, operation), CircuitClosed; got != want {
+		t.Errorf("state after the probe: got %s, want %s", got, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := fmt.Sprint(transitions), "[closed->open open->half-open half-open->closed]"; got != want {
+		t.Errorf("transitions: got %s, want %s", got, want)
+	}
+}
+
+func TestCircuitBreakerFailedProbe(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 1,
+		OpenTimeout:      20 * time.Millisecond,
+	})
+	client, mux := setupCircuitBreaker(t, breaker, nil)
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	handleProjects(mux, &healthy, &requests)
+	ctx := context.Background()
+
+	client.GetConnectProjects(ctx)
+	time.Sleep(30 * time.Millisecond)
+
+	_, err := client.GetConnectProjects(ctx)
+	var riError RapidIdentityError
+	if !errors.As(err, &riError) || riError.Code != http.StatusServiceUnavailable {
+		t.Fatalf("probe: got error %v, want a 503", err)
+	}
+	if _, err := client.GetConnectProjects(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("after the probe: got error %v, want ErrCircuitOpen", err)
+	}
+	if got, want := requests.Load(), int32(2); got != want {
+		t.Errorf("requests: got %d, want %d", got, want)
+	}
+}
+
+func TestCircuitBreakerFailures(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2})
+	client, mux := setupCircuitBreaker(t, breaker, nil)
+	mux.HandleFunc(baseUrlPath+"/admin/connect/actions/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	ctx := context.Background()
+
+	for range 3 {
+		_, err := client.GetConnectActionById(ctx, GetConnectActionByIdInput{Id: "missing"})
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got error %v, want ErrNotFound", err)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for range 3 {
+		client.GetConnectProjects(canceled)
+	}
+	for _, status := range breaker.Circuits() {
+		if status.State != CircuitClosed || status.Failures != 0 {
+			t.Errorf("got %+v, want a closed circuit without failures", status)
+		}
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	downUrl, _ := url.Parse(down.URL)
+	down.Close()
+	other, _ := New(Options{
+		HTTPClient:      &http.Client{},
+		ServiceIdentity: mockServiceIdentity,
+		BaseUrl:         downUrl,
+		CircuitBreaker:  breaker,
+	})
+	for range 2 {
+		other.GetConnectProjects(ctx)
+	}
+	if got, want := breaker.State(down.URL, opGetConnectProjects.String()), CircuitOpen; got != want {
+		t.Errorf("connection errors: got %s, want %s", got, want)
+	}
+	if _, err := client.GetConnectActionById(ctx, GetConnectActionByIdInput{Id: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other tenant: got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestCircuitBreakerRateLimit(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2})
+	client, mux := setupWithOptions(t, Options{
+		CircuitBreaker: breaker,
+		RateLimit:      &RateLimitOptions{Default: RateLimit{Rate: 1, Burst: 1}},
+	})
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	healthy.Store(true)
+	handleProjects(mux, &healthy, &requests)
+
+	// The limiter rejects the calls after the first one
+	// while their contexts are live, which is not a
+	// failure of the tenant.
+	for i := range 4 {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		_, err := client.GetConnectProjects(ctx)
+		cancel()
+		if errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: got ErrCircuitOpen, want the rate limit error", i)
+		}
+		if i > 0 && !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("call %d: got error %v, want context.DeadlineExceeded", i, err)
+		}
+	}
+	status := breaker.Circuits()[0]
+	if status.State != CircuitClosed || status.Failures != 0 {
+		t.Errorf("got %+v, want a closed circuit without failures", status)
+	}
+	if got, want := requests.Load(), int32(1); got != want {
+		t.Errorf("requests: got %d, want %d", got, want)
+	}
+}
+
+func TestCircuitBreakerStopsRetries(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2})
+	client, mux := setupCircuitBreaker(t, breaker, &RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+	})
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	handleProjects(mux, &healthy, &requests)
+
+	_, err := client.GetConnectProjects(context.Background())
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("got error %v, want ErrCircuitOpen", err)
+	}
+	if got, want := requests.Load(), int32(2); got != want {
+		t.Errorf("requests: got %d, want %d", got, want)
+	}
+}
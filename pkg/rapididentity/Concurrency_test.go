@@ -0,0 +1,225 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// These tests are meant to be run with -race.
+
+func TestConcurrentCalls(t *testing.T) {
+	t.Parallel()
+	client, mux, ss := setupSession(t, Options{})
+	defer client.Close()
+
+	var requests atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		// Expire the session every 10 requests.
+		if requests.Add(1)%10 == 0 {
+			ss.sessions.Add(1)
+		}
+		if !ss.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"projects": []}`)
+	})
+	mux.HandleFunc("GET "+baseUrlPath+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"session": {"id": "1"}}`)
+	})
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			switch i % 3 {
+			case 0:
+				_, err = client.GetConnectProjects(ctx)
+			case 1:
+				_, err = client.GetCurrentSession(ctx)
+			case 2:
+				_ = client.Session()
+				_, err = Do[GetConnectProjectsOutput](ctx, client, "GET", "admin/connect/projects", nil)
+			}
+			if err != nil && !errors.Is(err, ErrUnauthorized) {
+				t.Errorf("got error %s, want none", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	t.Parallel()
+	client, mux, _ := setupSession(t, Options{})
+
+	var deletes atomic.Int32
+	mux.HandleFunc("DELETE "+baseUrlPath+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		deletes.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Close(); err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := deletes.Load(), int32(1); got != want {
+		t.Errorf("session deletes: got %d, want %d", got, want)
+	}
+}
+
+func TestCloseContext(t *testing.T) {
+	t.Parallel()
+	var operations []Operation
+	client, mux, _ := setupSession(t, Options{
+		// The session creation takes the only token.
+		RateLimit: &RateLimitOptions{Default: RateLimit{Rate: 0.001, Burst: 1}},
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) error {
+				operations = append(operations, call.Operation)
+				return next(ctx, call)
+			}
+		}},
+	})
+	var deletes atomic.Int32
+	mux.HandleFunc("DELETE "+baseUrlPath+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		deletes.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.CloseContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want context.DeadlineExceeded", err)
+	}
+	if len(operations) != 1 || operations[0] != opDeleteSession {
+		t.Errorf("middleware operations: got %v, want %v", operations, opDeleteSession)
+	}
+	if got := deletes.Load(); got != 0 {
+		t.Errorf("session deletes: got %d, want 0", got)
+	}
+}
+
+func TestCloseDuringCalls(t *testing.T) {
+	t.Parallel()
+	client, mux, _ := setupSession(t, Options{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(started) })
+		<-release
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	ctx := context.Background()
+	inFlight := make(chan error)
+	go func() {
+		_, err := client.GetBootstrapInfo(ctx)
+		inFlight <- err
+	}()
+	<-started
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	close(release)
+	if err := <-inFlight; err != nil {
+		t.Errorf("in-flight call: got error %s, want none", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetBootstrapInfo(ctx); !errors.Is(err, ErrClientClosed) {
+				t.Errorf("got error %v, want ErrClientClosed", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCallsAfterClose(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	var requests atomic.Int32
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetBootstrapInfo(ctx); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("GetBootstrapInfo: got error %v, want ErrClientClosed", err)
+	}
+	if _, err := client.DoCustomRequest(ctx, "GET", "admin/workflow/resources", nil); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("DoCustomRequest: got error %v, want ErrClientClosed", err)
+	}
+	if _, err := Do[[]byte](ctx, client, "GET", "admin/workflow/resources", nil); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Do: got error %v, want ErrClientClosed", err)
+	}
+	if _, err := client.ProxyAs(ctx, ProxyAsInput{UserId: "08b5f0ec"}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("ProxyAs: got error %v, want ErrClientClosed", err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("requests sent: got %d, want 0", got)
+	}
+}
+
+func TestCloseDoesNotRenew(t *testing.T) {
+	t.Parallel()
+	client, mux, ss := setupSession(t, Options{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(started) })
+		<-release
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	inFlight := make(chan error)
+	go func() {
+		_, err := client.GetBootstrapInfo(context.Background())
+		inFlight <- err
+	}()
+	<-started
+	client.Close()
+	close(release)
+
+	if err := <-inFlight; !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got error %v, want ErrUnauthorized", err)
+	}
+	if got, want := ss.sessions.Load(), int32(1); got != want {
+		t.Errorf("sessions created: got %d, want %d", got, want)
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -1390,31 +1391,26 @@ type ShieldIdInfo struct {
 //
 //meta:operation POST /authn/v1/username
 func (c *Client) GetAuthenticationPoliciesForUser(ctx context.Context, params GetAuthenticationPoliciesForUserInput) (*GetAuthenticationPoliciesForUserOutput, error) {
-	url := fmt.Sprintf("%s/authn/v1/username?authenticationPolicies=%t&claim=%t", c.baseEndpoint, params.ShowAuthenticationPolicies, params.ShowClaims)
+	query := url.Values{}
+	query.Set("authenticationPolicies", strconv.FormatBool(params.ShowAuthenticationPolicies))
+	query.Set("claim", strconv.FormatBool(params.ShowClaims))
 	for _, field := range params.AuthenticationPolicyFieldsToShow {
-		url = fmt.Sprintf("%s&authenticationPolicyField=%s", url, field)
+		query.Add("authenticationPolicyField", field)
 	}
+	endpointUrl := c.endpoint("/authn/v1/username", query)
 	user, err := json.Marshal(params.User)
 	if err != nil {
 		return nil, err
 	}
 	requestBody := bytes.NewBuffer(user)
-	req, err := c.GenerateRequest(ctx, "POST", url, requestBody)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
-	if err != nil {
-		return nil, err
-	}
 	var output GetAuthenticationPoliciesForUserOutput
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetAuthenticationPoliciesForUser, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -1428,22 +1424,13 @@ func (c *Client) GetAuthenticationPoliciesForUser(ctx context.Context, params Ge
 func (c *Client) GetBootstrapInfo(ctx context.Context) (*GetBootstrapInfoOutput, error) {
 	var output GetBootstrapInfoOutput
 
-	url := fmt.Sprintf("%s/bootstrapInfo", c.baseEndpoint)
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
+	endpointUrl := c.endpoint("/bootstrapInfo", nil)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetBootstrapInfo, nil, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -1457,23 +1444,13 @@ func (c *Client) GetBootstrapInfo(ctx context.Context) (*GetBootstrapInfoOutput,
 func (c *Client) GetRapidIdentityAttributes(ctx context.Context) (StringList, error) {
 	var output StringList
 
-	url := fmt.Sprintf("%s/admin/ldap/schema/attributes", c.baseEndpoint)
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	resBody, err := c.ReceiveResponse(res)
+	endpointUrl := c.endpoint("/admin/ldap/schema/attributes", nil)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetRapidIdentityAttributes, nil, req, &output)
 	if err != nil {
 		return nil, err
 	}
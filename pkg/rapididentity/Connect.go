@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"net/url"
+	"strconv"
 )
 
 // BUG(Identity Automation): Downloading a compressed file is not possible with GetConnectFileContent. If compression is needed use GetConnectFileContentZip
@@ -69,6 +70,32 @@ type GetConnectFileContentZipInput struct {
 	Project string `json:"project" jsonschema:"The connect project name that the directory or file resides The default is the .Main project"`
 }
 
+// Input for streaming file content from the
+// Connect files module and logs.
+type StreamConnectFileContentInput struct {
+	GetConnectFileContentInput
+
+	// The number of bytes to skip, for resuming an
+	// interrupted download. Sent as a Range header.
+	Offset int64 `json:"offset" jsonschema:"The number of bytes to skip, for resuming an interrupted download. Sent as a Range header."`
+
+	// Called after each read with the bytes transferred.
+	Progress ProgressFunc `json:"-"`
+}
+
+// Input for streaming multiple files zipped from the
+// Connect files module and logs.
+type StreamConnectFileContentZipInput struct {
+	GetConnectFileContentZipInput
+
+	// The number of bytes to skip, for resuming an
+	// interrupted download. Sent as a Range header.
+	Offset int64 `json:"offset" jsonschema:"The number of bytes to skip, for resuming an interrupted download. Sent as a Range header."`
+
+	// Called after each read with the bytes transferred.
+	Progress ProgressFunc `json:"-"`
+}
+
 // Input for retrieving metadata on files from the
 // Connect files module and logs.
 type GetConnectFilesInput struct {
@@ -579,29 +606,16 @@ type DeleteConnectActionByIdOutput struct {
 func (c *Client) GetConnectActions(ctx context.Context, params GetConnectActionsInput) (*GetConnectActionsOutput, error) {
 	var output GetConnectActionsOutput
 
-	url := fmt.Sprintf("%s/admin/connect/actions?metaDataOnly=%t", c.baseEndpoint, params.MetaDataOnly)
-	if params.Project != "" {
-		if params.Project == MainProject {
-			url = fmt.Sprintf("%s&project=", url)
-		} else {
-			url = fmt.Sprintf("%s&project=%s", url, params.Project)
-		}
-	}
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	query := url.Values{}
+	query.Set("metaDataOnly", strconv.FormatBool(params.MetaDataOnly))
+	setProject(query, params.Project)
+	endpointUrl := c.endpoint("/admin/connect/actions", query)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetConnectActions, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -615,22 +629,15 @@ func (c *Client) GetConnectActions(ctx context.Context, params GetConnectActions
 func (c *Client) GetConnectActionById(ctx context.Context, params GetConnectActionByIdInput) (*GetConnectActionByIdOutput, error) {
 	var output ActionDef
 
-	url := fmt.Sprintf("%s/admin/connect/actions/%s?metaDataOnly=%t", c.baseEndpoint, params.Id, params.MetaDataOnly)
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
+	query := url.Values{}
+	query.Set("metaDataOnly", strconv.FormatBool(params.MetaDataOnly))
+	endpointUrl := c.endpoint("/admin/connect/actions/"+url.PathEscape(params.Id), query)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetConnectActionById, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -645,8 +652,11 @@ func (c *Client) GetConnectActionById(ctx context.Context, params GetConnectActi
 //
 //meta:operation GET /admin/connect/fileContent/{path}
 func (c *Client) GetConnectFileContent(ctx context.Context, params GetConnectFileContentInput) ([]byte, error) {
-	url := fmt.Sprintf("%s/admin/connect/fileContent/%s?project=%s&decompress=%t", c.baseEndpoint, params.Path, params.Project, params.Decompress)
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
+	query := url.Values{}
+	query.Set("project", params.Project)
+	query.Set("decompress", strconv.FormatBool(params.Decompress))
+	endpointUrl := c.endpoint("/admin/connect/fileContent/"+escapeFilePath(params.Path), query)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -657,11 +667,8 @@ func (c *Client) GetConnectFileContent(ctx context.Context, params GetConnectFil
 		req.Header.Set("Accept", params.ResponseType)
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	var resBody []byte
+	err = c.invoke(ctx, opGetConnectFileContent, params, req, &resBody)
 	if err != nil {
 		return nil, err
 	}
@@ -674,27 +681,79 @@ func (c *Client) GetConnectFileContent(ctx context.Context, params GetConnectFil
 //
 //meta:operation GET /admin/connect/fileContentZip
 func (c *Client) GetConnectFileContentZip(ctx context.Context, params GetConnectFileContentZipInput) ([]byte, error) {
-	url := fmt.Sprintf("%s/admin/connect/fileContentZip?project=%s", c.baseEndpoint, params.Project)
+	query := url.Values{}
+	query.Set("project", params.Project)
 	for _, path := range params.PathList {
-		url = fmt.Sprintf("%s&path=%s", url, path)
+		query.Add("path", path)
 	}
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
+	endpointUrl := c.endpoint("/admin/connect/fileContentZip", query)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Accept", "application/zip")
 
-	res, err := c.httpClient.Do(req)
+	var resBody []byte
+	err = c.invoke(ctx, opGetConnectFileContentZip, params, req, &resBody)
 	if err != nil {
 		return nil, err
 	}
-	resBody, err := c.ReceiveResponse(res)
+
+	return resBody, nil
+}
+
+// Streams file content from a file within the Connect files
+// module and logs, such as large job logs, without reading it
+// into memory. Set Offset to resume an interrupted download.
+//
+//	stream, err := client.StreamConnectFileContent(ctx, input)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	_, err = io.Copy(file, stream)
+//
+//meta:operation GET /admin/connect/fileContent/{path}
+func (c *Client) StreamConnectFileContent(ctx context.Context, params StreamConnectFileContentInput) (*FileStream, error) {
+	query := url.Values{}
+	query.Set("project", params.Project)
+	query.Set("decompress", strconv.FormatBool(params.Decompress))
+	endpointUrl := c.endpoint("/admin/connect/fileContent/"+escapeFilePath(params.Path), query)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return resBody, nil
+	if params.ResponseType == "" {
+		req.Header.Set("Accept", "text/plain")
+	} else {
+		req.Header.Set("Accept", params.ResponseType)
+	}
+
+	return c.stream(ctx, opStreamConnectFileContent, params, req, params.Offset, params.Progress)
+}
+
+// Streams multiple files zipped from the Connect files
+// module and logs without reading the archive into memory.
+// Set Offset to resume an interrupted download.
+//
+//meta:operation GET /admin/connect/fileContentZip
+func (c *Client) StreamConnectFileContentZip(ctx context.Context, params StreamConnectFileContentZipInput) (*FileStream, error) {
+	query := url.Values{}
+	query.Set("project", params.Project)
+	for _, path := range params.PathList {
+		query.Add("path", path)
+	}
+	endpointUrl := c.endpoint("/admin/connect/fileContentZip", query)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/zip")
+
+	return c.stream(ctx, opStreamConnectFileContentZip, params, req, params.Offset, params.Progress)
 }
 
 // Retrieves metadata for files within the Connect files
@@ -705,8 +764,10 @@ func (c *Client) GetConnectFileContentZip(ctx context.Context, params GetConnect
 func (c *Client) GetConnectFiles(ctx context.Context, params GetConnectFilesInput) (*GetConnectFilesOutput, error) {
 	var output GetConnectFilesOutput
 
-	url := fmt.Sprintf("%s/admin/connect/files/%s?project=%s", c.baseEndpoint, params.Path, params.Project)
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
+	query := url.Values{}
+	query.Set("project", params.Project)
+	endpointUrl := c.endpoint("/admin/connect/files/"+escapeFilePath(params.Path), query)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -715,16 +776,7 @@ func (c *Client) GetConnectFiles(ctx context.Context, params GetConnectFilesInpu
 		req.Header.Set("Accept", params.ResponseType)
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetConnectFiles, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -738,31 +790,16 @@ func (c *Client) GetConnectFiles(ctx context.Context, params GetConnectFilesInpu
 func (c *Client) GetConnectJobs(ctx context.Context, params GetConnectJobsInput) (*GetConnectJobsOutput, error) {
 	var output GetConnectJobsOutput
 
-	url := fmt.Sprintf("%s/admin/connect/jobs", c.baseEndpoint)
-	if params.Project != "" {
-		if params.Project == MainProject {
-			url = fmt.Sprintf("%s?project=", url)
-		} else {
-			url = fmt.Sprintf("%s?project=%s", url, params.Project)
-		}
-	}
-
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
+	query := url.Values{}
+	setProject(query, params.Project)
+	endpointUrl := c.endpoint("/admin/connect/jobs", query)
 
-	resBody, err := c.ReceiveResponse(res)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetConnectJobs, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -776,24 +813,14 @@ func (c *Client) GetConnectJobs(ctx context.Context, params GetConnectJobsInput)
 func (c *Client) GetConnectProjects(ctx context.Context) (*GetConnectProjectsOutput, error) {
 	var output GetConnectProjectsOutput
 
-	url := fmt.Sprintf("%s/admin/connect/projects", c.baseEndpoint)
+	endpointUrl := c.endpoint("/admin/connect/projects", nil)
 
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	resBody, err := c.ReceiveResponse(res)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetConnectProjects, nil, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -807,29 +834,19 @@ func (c *Client) GetConnectProjects(ctx context.Context) (*GetConnectProjectsOut
 func (c *Client) SearchConnectActionSets(ctx context.Context, params SearchConnectActionSetsInput) (*SearchConnectActionSetsOutput, error) {
 	var output SearchConnectActionSetsOutput
 
-	url := fmt.Sprintf("%s/admin/connect/search/actions?searchString=%s&matchAction=%t&matchCase=%t&regex=%t", c.baseEndpoint, params.SearchString, params.MatchAction, params.MatchCase, params.Regex)
-	if params.Project != "" {
-		if params.Project == MainProject {
-			url = fmt.Sprintf("%s&project=", url)
-		} else {
-			url = fmt.Sprintf("%s&project=%s", url, params.Project)
-		}
-	}
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
+	query := url.Values{}
+	query.Set("searchString", params.SearchString)
+	query.Set("matchAction", strconv.FormatBool(params.MatchAction))
+	query.Set("matchCase", strconv.FormatBool(params.MatchCase))
+	query.Set("regex", strconv.FormatBool(params.Regex))
+	setProject(query, params.Project)
+	endpointUrl := c.endpoint("/admin/connect/search/actions", query)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opSearchConnectActionSets, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -841,28 +858,20 @@ func (c *Client) SearchConnectActionSets(ctx context.Context, params SearchConne
 //
 //meta:operation POST /admin/connect/actions
 func (c *Client) SaveConnectAction(ctx context.Context, params SaveConnectActionInput) (*SaveConnectActionOutput, error) {
-	url := fmt.Sprintf("%s/admin/connect/actions", c.baseEndpoint)
+	endpointUrl := c.endpoint("/admin/connect/actions", nil)
 	action, err := json.Marshal(params.Action)
 	if err != nil {
 		return nil, err
 	}
 	requestBody := bytes.NewBuffer(action)
-	req, err := c.GenerateRequest(ctx, "POST", url, requestBody)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
-	if err != nil {
-		return nil, err
-	}
 	var output ActionDef
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opSaveConnectAction, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -876,24 +885,21 @@ func (c *Client) SaveConnectAction(ctx context.Context, params SaveConnectAction
 //
 //meta:operation POST /admin/connect/run
 func (c *Client) RunConnectAction(ctx context.Context, params RunConnectActionInput) (*RunConnectActionOutput, error) {
-	url := fmt.Sprintf("%s/admin/connect/run", c.baseEndpoint)
+	endpointUrl := c.endpoint("/admin/connect/run", nil)
 	action, err := json.Marshal(params.Action)
 	if err != nil {
 		return nil, err
 	}
 	requestBody := bytes.NewBuffer(action)
-	req, err := c.GenerateRequest(ctx, "POST", url, requestBody)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Set("Accept", "text/html")
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	var resBody []byte
+	err = c.invoke(ctx, opRunConnectAction, params, req, &resBody)
 	if err != nil {
 		return nil, err
 	}
@@ -909,22 +915,13 @@ func (c *Client) RunConnectAction(ctx context.Context, params RunConnectActionIn
 func (c *Client) DeleteConnectActionById(ctx context.Context, params DeleteConnectActionByIdInput) (*DeleteConnectActionByIdOutput, error) {
 	var output OperationStatus
 
-	url := fmt.Sprintf("%s/admin/connect/actions/%s", c.baseEndpoint, params.Id)
-	req, err := c.GenerateRequest(ctx, "DELETE", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	endpointUrl := c.endpoint("/admin/connect/actions/"+url.PathEscape(params.Id), nil)
+	req, err := c.GenerateRequest(ctx, "DELETE", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opDeleteConnectActionById, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
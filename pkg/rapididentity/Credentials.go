@@ -0,0 +1,448 @@
+package rapididentity
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables read by EnvProvider
+// and ProfileProvider.
+const (
+	EnvUrl             = "RI_URL"
+	EnvServiceIdentity = "RI_KEY"
+	EnvUsername        = "RI_USER"
+	EnvPassword        = "RI_PWD"
+	EnvProfile         = "RI_PROFILE"
+	EnvConfigFile      = "RI_CONFIG_FILE"
+)
+
+// The profile used when RI_PROFILE is not set.
+const DefaultProfile = "default"
+
+// Returned by a CredentialsProvider that has no
+// credentials. ChainProvider moves on to the next
+// provider when this error is returned.
+var ErrNoCredentials = errors.New("rapididentity: no credentials found")
+
+// The tenant and the credentials resolved
+// by a CredentialsProvider.
+type Credentials struct {
+	// The rapididentity base host url.
+	// May be nil if the provider does not know it.
+	BaseUrl *url.URL
+
+	// The service identity key.
+	ServiceIdentity string
+
+	// The RapidIdentity user to create
+	// a session. Nil if ServiceIdentity is used.
+	RapidIdentityUser *RapidIdentityUser
+
+	// The name of the provider the credentials
+	// were resolved by, for example "env" or
+	// "profile:default".
+	Source string
+}
+
+// Logs the source and the base url
+// without the secrets.
+func (c Credentials) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("source", c.Source)}
+	if c.BaseUrl != nil {
+		attrs = append(attrs, slog.String("baseUrl", c.BaseUrl.String()))
+	}
+	if c.ServiceIdentity != "" {
+		attrs = append(attrs, slog.String("serviceIdentity", redacted))
+	}
+	if c.RapidIdentityUser != nil {
+		attrs = append(attrs, slog.Any("rapidIdentityUser", *c.RapidIdentityUser))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Resolves the tenant and the credentials
+// to create a Client with.
+type CredentialsProvider interface {
+	// Returns the credentials or an error wrapping
+	// ErrNoCredentials when the provider has none.
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// Reads the credentials from the RI_URL, RI_KEY,
+// RI_USER and RI_PWD environment variables. RI_KEY
+// takes precedence over RI_USER and RI_PWD.
+type EnvProvider struct{}
+
+func (EnvProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	credentials := Credentials{Source: "env"}
+	switch {
+	case os.Getenv(EnvServiceIdentity) != "":
+		credentials.ServiceIdentity = os.Getenv(EnvServiceIdentity)
+	case os.Getenv(EnvUsername) != "":
+		credentials.RapidIdentityUser = &RapidIdentityUser{
+			Username: os.Getenv(EnvUsername),
+			Password: os.Getenv(EnvPassword),
+		}
+	default:
+		return Credentials{}, fmt.Errorf("%w: %s and %s are not set", ErrNoCredentials, EnvServiceIdentity, EnvUsername)
+	}
+
+	if rawUrl := os.Getenv(EnvUrl); rawUrl != "" {
+		baseUrl, err := parseBaseUrl(rawUrl)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("rapididentity: invalid %s: %w", EnvUrl, err)
+		}
+		credentials.BaseUrl = baseUrl
+	}
+
+	return credentials, nil
+}
+
+// Reads the credentials of a named profile from the
+// profiles config file. The file holds one section per
+// tenant with the url and either service_identity,
+// username and password, or a credential_process that
+// is run with CommandProvider.
+//
+//	[default]
+//	url = https://portal.us001-rapididentity.com
+//	service_identity = service_identity_key
+//
+//	[district2]
+//	url = https://portal.us002-rapididentity.com
+//	credential_process = vault-ri-credentials district2
+//
+// Lines starting with '#' or ';' are comments. The
+// credential_process is split into arguments with shell
+// quoting, so paths with spaces must be quoted.
+type ProfileProvider struct {
+	// The path of the config file. The default is
+	// RI_CONFIG_FILE or ~/.rapididentity/config.
+	Path string
+
+	// The name of the profile. The default
+	// is RI_PROFILE or "default".
+	Profile string
+}
+
+func (p ProfileProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	path, err := p.path()
+	if err != nil {
+		return Credentials{}, fmt.Errorf("%w: %w", ErrNoCredentials, err)
+	}
+	profileName := p.profile()
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Credentials{}, fmt.Errorf("%w: config file %s does not exist", ErrNoCredentials, path)
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer file.Close()
+
+	profiles, err := parseProfiles(file)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("rapididentity: invalid config file %s: %w", path, err)
+	}
+	profile, ok := profiles[profileName]
+	if !ok {
+		return Credentials{}, fmt.Errorf("%w: profile %q not found in %s", ErrNoCredentials, profileName, path)
+	}
+
+	source := "profile:" + profileName
+	var credentials Credentials
+	switch {
+	case profile["credential_process"] != "":
+		args, err := splitCommand(profile["credential_process"])
+		if err != nil {
+			return Credentials{}, fmt.Errorf("rapididentity: profile %q: invalid credential_process: %w", profileName, err)
+		}
+		credentials, err = CommandProvider{Command: args[0], Args: args[1:]}.Retrieve(ctx)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("rapididentity: profile %q: %w", profileName, err)
+		}
+	case profile["service_identity"] != "":
+		credentials.ServiceIdentity = profile["service_identity"]
+	case profile["username"] != "":
+		credentials.RapidIdentityUser = &RapidIdentityUser{
+			Username: profile["username"],
+			Password: profile["password"],
+		}
+	default:
+		return Credentials{}, fmt.Errorf("%w: profile %q has no credentials", ErrNoCredentials, profileName)
+	}
+	credentials.Source = source
+
+	if rawUrl := profile["url"]; rawUrl != "" {
+		baseUrl, err := parseBaseUrl(rawUrl)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("rapididentity: profile %q: invalid url: %w", profileName, err)
+		}
+		credentials.BaseUrl = baseUrl
+	}
+
+	return credentials, nil
+}
+
+// Splits a command line into its arguments following
+// the shell quoting rules: single quotes keep their
+// content as is, double quotes and backslashes escape
+// the next character, so that paths and arguments may
+// contain spaces.
+//
+//	"/opt/ri tools/credentials" --profile 'district 2'
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\' && (quote == 0 || quote == '"'):
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %s", command)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+func (p ProfileProvider) path() (string, error) {
+	if p.Path != "" {
+		return p.Path, nil
+	}
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".rapididentity", "config"), nil
+}
+
+func (p ProfileProvider) profile() string {
+	if p.Profile != "" {
+		return p.Profile
+	}
+	if profile := os.Getenv(EnvProfile); profile != "" {
+		return profile
+	}
+	return DefaultProfile
+}
+
+// Parses the sections of the profiles config
+// file into their key value pairs.
+func parseProfiles(file *os.File) (map[string]map[string]string, error) {
+	profiles := map[string]map[string]string{}
+	var section map[string]string
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			section = map[string]string{}
+			profiles[name] = section
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+		if section == nil {
+			return nil, fmt.Errorf("line %d: %s is not in a profile section", lineNumber, strings.TrimSpace(key))
+		}
+		section[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return profiles, scanner.Err()
+}
+
+// Runs an external command that prints the credentials
+// as JSON to stdout, for example to read them from a
+// secrets manager.
+//
+//	{
+//		"url": "https://portal.us001-rapididentity.com",
+//		"serviceIdentity": "service_identity_key"
+//	}
+//
+// A user session is created with "username" and
+// "password" instead of "serviceIdentity".
+type CommandProvider struct {
+	// The command to run.
+	Command string
+
+	// The arguments of the command.
+	Args []string
+}
+
+func (p CommandProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return Credentials{}, fmt.Errorf("rapididentity: credential command %s: %w: %s", p.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var output struct {
+		Url             string `json:"url"`
+		ServiceIdentity string `json:"serviceIdentity"`
+		Username        string `json:"username"`
+		Password        string `json:"password"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return Credentials{}, fmt.Errorf("rapididentity: credential command %s: invalid output: %w", p.Command, err)
+	}
+
+	credentials := Credentials{Source: "command:" + p.Command}
+	switch {
+	case output.ServiceIdentity != "":
+		credentials.ServiceIdentity = output.ServiceIdentity
+	case output.Username != "":
+		credentials.RapidIdentityUser = &RapidIdentityUser{
+			Username: output.Username,
+			Password: output.Password,
+		}
+	default:
+		return Credentials{}, fmt.Errorf("%w: credential command %s returned no credentials", ErrNoCredentials, p.Command)
+	}
+
+	if output.Url != "" {
+		baseUrl, err := parseBaseUrl(output.Url)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("rapididentity: credential command %s: invalid url: %w", p.Command, err)
+		}
+		credentials.BaseUrl = baseUrl
+	}
+
+	return credentials, nil
+}
+
+// Tries each provider in order and returns the
+// credentials of the first one that has them. Errors
+// other than ErrNoCredentials stop the chain.
+type ChainProvider []CredentialsProvider
+
+func (chain ChainProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	errs := []error{ErrNoCredentials}
+	for _, provider := range chain {
+		credentials, err := provider.Retrieve(ctx)
+		if err == nil {
+			return credentials, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return Credentials{}, err
+		}
+		errs = append(errs, err)
+	}
+	return Credentials{}, errors.Join(errs...)
+}
+
+// Returns the provider chain used by NewFromEnvironment,
+// the environment variables followed by the profile
+// selected with RI_PROFILE.
+func DefaultCredentialsChain() CredentialsProvider {
+	return ChainProvider{EnvProvider{}, ProfileProvider{}}
+}
+
+// Creates a new RapidIdentity Client with the BaseUrl
+// and credentials resolved by options.Credentials,
+// or by DefaultCredentialsChain if it is nil. A BaseUrl,
+// ServiceIdentity or RapidIdentityUser set in the options
+// takes precedence over the resolved values.
+//
+//	client, err := rapididentity.NewFromEnvironment(rapididentity.Options{})
+func NewFromEnvironment(options Options) (*Client, error) {
+	if options.Credentials == nil {
+		options.Credentials = DefaultCredentialsChain()
+	}
+	return New(options)
+}
+
+// Resolves options.Credentials into the BaseUrl
+// and credentials that are not already set. The
+// provider is not called when both are set.
+func resolveCredentials(ctx context.Context, options Options) (Options, error) {
+	if options.BaseUrl != nil && (options.ServiceIdentity != "" || options.RapidIdentityUser != nil) {
+		return options, nil
+	}
+
+	credentials, err := options.Credentials.Retrieve(ctx)
+	if err != nil {
+		return options, err
+	}
+
+	if options.BaseUrl == nil {
+		options.BaseUrl = credentials.BaseUrl
+	}
+	if options.ServiceIdentity == "" && options.RapidIdentityUser == nil {
+		options.ServiceIdentity = credentials.ServiceIdentity
+		options.RapidIdentityUser = credentials.RapidIdentityUser
+	}
+	if options.BaseUrl == nil {
+		return options, fmt.Errorf("rapididentity: no base url found for credentials from %s", credentials.Source)
+	}
+
+	return options, nil
+}
+
+func parseBaseUrl(rawUrl string) (*url.URL, error) {
+	baseUrl, err := url.Parse(strings.TrimSuffix(rawUrl, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if baseUrl.Scheme == "" || baseUrl.Host == "" {
+		return nil, fmt.Errorf("%s is not an absolute url", rawUrl)
+	}
+	return baseUrl, nil
+}
@@ -0,0 +1,389 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const mockConfig = `# RapidIdentity tenants
+[default]
+url = https://portal.us001-rapididentity.com/
+service_identity = default_key
+
+; user session
+[district2]
+url = https://portal.us002-rapididentity.com
+username = rapididentity@example.com
+password = donottellanyone
+
+[process]
+url = https://portal.us003-rapididentity.com
+credential_process = %s -test.run=TestCredentialsHelperProcess
+
+[empty]
+url = https://portal.us004-rapididentity.com
+`
+
+// Not a real test. Run by the CommandProvider
+// tests as the external credential command.
+func TestCredentialsHelperProcess(t *testing.T) {
+	if os.Getenv("RI_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	fmt.Print(os.Getenv("RI_HELPER_OUTPUT"))
+	os.Exit(0)
+}
+
+func writeConfig(t *testing.T) string {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, fmt.Appendf(nil, mockConfig, executable), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{EnvUrl, EnvServiceIdentity, EnvUsername, EnvPassword, EnvProfile, EnvConfigFile} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	clearEnv(t)
+	ctx := context.Background()
+
+	if _, err := (EnvProvider{}).Retrieve(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got error %v, want ErrNoCredentials", err)
+	}
+
+	t.Setenv(EnvUrl, "https://portal.us001-rapididentity.com")
+	t.Setenv(EnvUsername, mockUsername)
+	t.Setenv(EnvPassword, mockPassword)
+	credentials, err := EnvProvider{}.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := *credentials.RapidIdentityUser, (RapidIdentityUser{Username: mockUsername, Password: mockPassword}); got != want {
+		t.Errorf("user: got %+v, want %+v", got, want)
+	}
+	if got, want := credentials.BaseUrl.String(), "https://portal.us001-rapididentity.com"; got != want {
+		t.Errorf("base url: got %s, want %s", got, want)
+	}
+
+	t.Setenv(EnvServiceIdentity, mockServiceIdentity)
+	credentials, err = EnvProvider{}.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if credentials.ServiceIdentity != mockServiceIdentity || credentials.RapidIdentityUser != nil {
+		t.Errorf("credentials: got %+v, want service identity only", credentials)
+	}
+
+	t.Setenv(EnvUrl, "portal.us001-rapididentity.com")
+	if _, err := (EnvProvider{}).Retrieve(ctx); err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got error %v, want invalid url error", err)
+	}
+}
+
+func TestProfileProvider(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("RI_WANT_HELPER_PROCESS", "1")
+	t.Setenv("RI_HELPER_OUTPUT", `{"serviceIdentity": "process_key"}`)
+	path := writeConfig(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		profile         string
+		url             string
+		serviceIdentity string
+		username        string
+	}{
+		{profile: "", url: "https://portal.us001-rapididentity.com", serviceIdentity: "default_key"},
+		{profile: "district2", url: "https://portal.us002-rapididentity.com", username: mockUsername},
+		{profile: "process", url: "https://portal.us003-rapididentity.com", serviceIdentity: "process_key"},
+	}
+
+	for _, tt := range tests {
+		credentials, err := ProfileProvider{Path: path, Profile: tt.profile}.Retrieve(ctx)
+		if err != nil {
+			t.Errorf("profile %q: got error %s, want none", tt.profile, err)
+			continue
+		}
+		if got := credentials.BaseUrl.String(); got != tt.url {
+			t.Errorf("profile %q base url: got %s, want %s", tt.profile, got, tt.url)
+		}
+		if got := credentials.ServiceIdentity; got != tt.serviceIdentity {
+			t.Errorf("profile %q service identity: got %s, want %s", tt.profile, got, tt.serviceIdentity)
+		}
+		if tt.username != "" && (credentials.RapidIdentityUser == nil || credentials.RapidIdentityUser.Username != tt.username) {
+			t.Errorf("profile %q user: got %+v, want %s", tt.profile, credentials.RapidIdentityUser, tt.username)
+		}
+	}
+
+	for _, profile := range []string{"empty", "missing"} {
+		if _, err := (ProfileProvider{Path: path, Profile: profile}).Retrieve(ctx); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("profile %q: got error %v, want ErrNoCredentials", profile, err)
+		}
+	}
+
+	missingPath := filepath.Join(t.TempDir(), "config")
+	if _, err := (ProfileProvider{Path: missingPath}).Retrieve(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("missing file: got error %v, want ErrNoCredentials", err)
+	}
+
+	t.Setenv(EnvConfigFile, path)
+	t.Setenv(EnvProfile, "district2")
+	credentials, err := ProfileProvider{}.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := credentials.Source, "profile:district2"; got != want {
+		t.Errorf("source: got %s, want %s", got, want)
+	}
+}
+
+func TestProfileProviderQuotedCommand(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("RI_WANT_HELPER_PROCESS", "1")
+	t.Setenv("RI_HELPER_OUTPUT", `{"serviceIdentity": "process_key"}`)
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "ri tools")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	helper := filepath.Join(dir, "credentials helper")
+	if err := os.Symlink(executable, helper); err != nil {
+		t.Skipf("symlinks unsupported: %s", err)
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	config := fmt.Sprintf("[quoted]\nurl = https://portal.us005-rapididentity.com\ncredential_process = \"%s\" '-test.run=TestCredentialsHelperProcess'\n", helper)
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, err := ProfileProvider{Path: path, Profile: "quoted"}.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := credentials.ServiceIdentity, "process_key"; got != want {
+		t.Errorf("service identity: got %s, want %s", got, want)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{command: "vault-ri-credentials district2", want: []string{"vault-ri-credentials", "district2"}},
+		{command: `"/opt/ri tools/credentials" --profile 'district 2'`, want: []string{"/opt/ri tools/credentials", "--profile", "district 2"}},
+		{command: `creds --name=a\ b "say \"hi\"" '' x`, want: []string{"creds", "--name=a b", `say "hi"`, "", "x"}},
+		{command: "  creds\t  ", want: []string{"creds"}},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if err != nil {
+			t.Errorf("%s: got error %s, want none", tt.command, err)
+			continue
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.command, got, tt.want)
+		}
+	}
+
+	for _, command := range []string{"", "  ", `creds "district 2`, `creds 'x`, `creds \`} {
+		if _, err := splitCommand(command); err == nil {
+			t.Errorf("%q: got no error, want one", command)
+		}
+	}
+}
+
+func TestProfileProviderInvalidConfig(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("url = https://portal.us001-rapididentity.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ProfileProvider{Path: path}.Retrieve(context.Background())
+	if err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got error %v, want invalid config error", err)
+	}
+}
+
+func TestCommandProvider(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := CommandProvider{Command: executable, Args: []string{"-test.run=TestCredentialsHelperProcess"}}
+	t.Setenv("RI_WANT_HELPER_PROCESS", "1")
+	ctx := context.Background()
+
+	t.Setenv("RI_HELPER_OUTPUT", `{"url": "https://portal.us001-rapididentity.com", "username": "rapididentity@example.com", "password": "donottellanyone"}`)
+	credentials, err := provider.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := *credentials.RapidIdentityUser, (RapidIdentityUser{Username: mockUsername, Password: mockPassword}); got != want {
+		t.Errorf("user: got %+v, want %+v", got, want)
+	}
+	if got, want := credentials.BaseUrl.Host, "portal.us001-rapididentity.com"; got != want {
+		t.Errorf("base url host: got %s, want %s", got, want)
+	}
+
+	t.Setenv("RI_HELPER_OUTPUT", `{}`)
+	if _, err := provider.Retrieve(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got error %v, want ErrNoCredentials", err)
+	}
+
+	t.Setenv("RI_HELPER_OUTPUT", `not json`)
+	if _, err := provider.Retrieve(ctx); err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got error %v, want invalid output error", err)
+	}
+
+	missing := CommandProvider{Command: filepath.Join(t.TempDir(), "missing")}
+	if _, err := missing.Retrieve(ctx); err == nil {
+		t.Error("got no error, want error for missing command")
+	}
+}
+
+type staticProvider struct {
+	credentials Credentials
+	err         error
+	called      *bool
+}
+
+func (p staticProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	if p.called != nil {
+		*p.called = true
+	}
+	return p.credentials, p.err
+}
+
+func TestChainProvider(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	noCredentials := staticProvider{err: fmt.Errorf("%w: none here", ErrNoCredentials)}
+	found := staticProvider{credentials: Credentials{ServiceIdentity: mockServiceIdentity, Source: "found"}}
+
+	credentials, err := ChainProvider{noCredentials, found}.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := credentials.Source, "found"; got != want {
+		t.Errorf("source: got %s, want %s", got, want)
+	}
+
+	if _, err := (ChainProvider{noCredentials, noCredentials}).Retrieve(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got error %v, want ErrNoCredentials", err)
+	}
+
+	var called bool
+	failure := errors.New("secrets manager unavailable")
+	_, err = ChainProvider{staticProvider{err: failure}, staticProvider{called: &called}}.Retrieve(ctx)
+	if !errors.Is(err, failure) {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+	if called {
+		t.Error("provider after failure was called, want chain to stop")
+	}
+}
+
+func TestNewFromEnvironment(t *testing.T) {
+	clearEnv(t)
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer "+mockServiceIdentity)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	t.Setenv(EnvUrl, server.URL)
+	t.Setenv(EnvServiceIdentity, mockServiceIdentity)
+	client, err := NewFromEnvironment(Options{})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	defer client.Close()
+
+	if _, err := client.GetBootstrapInfo(context.Background()); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
+
+func TestNewFromEnvironmentOptionsTakePrecedence(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer "+mockServiceIdentity)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	baseUrl, _ := url.Parse(server.URL)
+	otherUrl, _ := url.Parse("https://portal.us001-rapididentity.com")
+	var called bool
+	found := staticProvider{credentials: Credentials{BaseUrl: otherUrl, ServiceIdentity: "other_key", Source: "found"}, called: &called}
+	client, err := NewFromEnvironment(Options{
+		BaseUrl:         baseUrl,
+		ServiceIdentity: mockServiceIdentity,
+		Credentials:     found,
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if called {
+		t.Error("provider was called, want it skipped when everything is set")
+	}
+
+	// A provider without credentials is not
+	// an error when none are needed.
+	none := staticProvider{err: ErrNoCredentials}
+	if _, err := NewFromEnvironment(Options{BaseUrl: baseUrl, ServiceIdentity: mockServiceIdentity, Credentials: none}); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+
+	// The url alone is resolved by the provider.
+	client, err = NewFromEnvironment(Options{BaseUrl: baseUrl, Credentials: staticProvider{credentials: Credentials{ServiceIdentity: mockServiceIdentity}}})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if _, err := client.GetBootstrapInfo(context.Background()); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
+
+func TestNewFromEnvironmentErrors(t *testing.T) {
+	t.Parallel()
+	noCredentials := staticProvider{err: ErrNoCredentials}
+	if _, err := NewFromEnvironment(Options{Credentials: noCredentials}); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("got error %v, want ErrNoCredentials", err)
+	}
+
+	noUrl := staticProvider{credentials: Credentials{ServiceIdentity: mockServiceIdentity, Source: "found"}}
+	if _, err := NewFromEnvironment(Options{Credentials: noUrl}); err == nil {
+		t.Error("got no error, want error for missing base url")
+	}
+}
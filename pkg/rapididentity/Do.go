@@ -0,0 +1,50 @@
+package rapididentity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// Do makes a typed API call to an endpoint that is not
+// wrapped by the client methods. The path should be the
+// endpoint path after /api/rest/ and may include an
+// escaped query, for example admin/workflow/resources.
+//
+// The input is sent as the JSON request body unless it
+// is nil. The response is checked the same way as the
+// client methods and decoded into Out. Use []byte as Out
+// to receive the raw response body.
+//
+//	type Regex struct {
+//		Valid bool `json:"valid"`
+//	}
+//	out, err := rapididentity.Do[Regex](ctx, client, "POST", "util/regex/v2/validate", input)
+func Do[Out any](ctx context.Context, c *Client, method string, path string, in any) (*Out, error) {
+	var requestBody io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		requestBody = bytes.NewReader(payload)
+	}
+
+	endpointUrl := c.endpoint("/"+path, nil)
+	req, err := c.GenerateRequest(ctx, method, endpointUrl, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	if requestBody != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	var output Out
+	err = c.invoke(ctx, customOperation("Do", method, path), in, req, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
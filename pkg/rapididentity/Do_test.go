@@ -0,0 +1,141 @@
+package rapididentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+type regexInput struct {
+	Regex string `json:"regex"`
+}
+
+type regexOutput struct {
+	Valid bool `json:"valid"`
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/util/regex/v2/validate", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testHeader(t, r, "Authorization", "Bearer "+mockServiceIdentity)
+		testHeader(t, r, "Content-Type", "application/json")
+		testHeader(t, r, "Accept", "application/json")
+		testQueryParam(t, r, "strict", "true")
+		var input regexInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("decode request body: %s", err)
+		}
+		if got, want := input.Regex, "^a+$"; got != want {
+			t.Errorf("request regex: got %s, want %s", got, want)
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"valid": true}`)
+	})
+
+	output, err := Do[regexOutput](context.Background(), client, "POST", "util/regex/v2/validate?strict=true", regexInput{Regex: "^a+$"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if !output.Valid {
+		t.Errorf("output valid: got false, want true")
+	}
+}
+
+func TestDoWithoutInput(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/workflow/resources", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testHeader(t, r, "Content-Type", "")
+		if body, _ := io.ReadAll(r.Body); len(body) != 0 {
+			t.Errorf("request body: got %s, want none", body)
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `[{"id": "1"}, {"id": "2"}]`)
+	})
+
+	output, err := Do[[]map[string]string](context.Background(), client, "GET", "admin/workflow/resources", nil)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := len(*output), 2; got != want {
+		t.Errorf("output length: got %d, want %d", got, want)
+	}
+}
+
+func TestDoRawBody(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/workflow/export", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "not json")
+	})
+
+	output, err := Do[[]byte](context.Background(), client, "GET", "admin/workflow/export", nil)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := string(*output), "not json"; got != want {
+		t.Errorf("output: got %s, want %s", got, want)
+	}
+}
+
+func TestDoErrors(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/workflow/resources/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "resource not found"}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"valid": "yes"}`)
+	})
+
+	ctx := context.Background()
+	_, err := Do[regexOutput](ctx, client, "GET", "admin/workflow/resources/missing", nil)
+	var riError RapidIdentityError
+	if !errors.As(err, &riError) || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got error %v, want RapidIdentityError matching ErrNotFound", err)
+	}
+	if got, want := riError.Reason, "resource not found"; got != want {
+		t.Errorf("reason: got %s, want %s", got, want)
+	}
+
+	_, err = Do[regexOutput](ctx, client, "GET", "admin/workflow/resources/1234", nil)
+	if !errors.Is(err, ErrDecode) {
+		t.Errorf("errors.Is(%v, ErrDecode): got false, want true", err)
+	}
+}
+
+func TestDoOperation(t *testing.T) {
+	t.Parallel()
+	var got Operation
+	client, mux := setup(t)
+	client.handler = chain([]Middleware{func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			got = call.Operation
+			return next(ctx, call)
+		}
+	}}, client.handle)
+	mux.HandleFunc(baseUrlPath+"/admin/workflow/resources", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `[]`)
+	})
+
+	_, err := Do[[]any](context.Background(), client, "GET", "admin/workflow/resources?limit=5", nil)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	want := Operation{Name: "Do", Method: "GET", Path: "/admin/workflow/resources", Idempotent: true}
+	if got != want {
+		t.Errorf("operation: got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,297 @@
+package rapididentity
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Options for detecting drift between the responses
+// of the tenant and the output types of the Client,
+// for example after a RapidIdentity upgrade. Every
+// decoded response is compared field by field with its
+// output type, which roughly doubles the decoding cost.
+type StrictDecodingOptions struct {
+	// Called with the report of every response with
+	// extra or missing fields. It is called synchronously,
+	// so it must not block. Use DriftCollector.Record to
+	// collect the reports. Drift is also logged at warn
+	// level when Options.Logger is set.
+	OnDrift func(report DriftReport)
+}
+
+// The fields of a response that do not match
+// the output type of the operation.
+type DriftReport struct {
+	// The operation in the //meta:operation format.
+	// For example "GET /admin/connect/jobs".
+	Operation string
+
+	// The Go type the response was decoded into.
+	// For example rapididentity.GetConnectJobsOutput.
+	OutputType string
+
+	// The JSON fields of the response that the output
+	// type does not have, so their values are dropped.
+	// Paths are dotted, with [] for array elements and
+	// * for map values, for example jobs[].schedule.
+	Extra []string
+
+	// The JSON fields of the output type that were not
+	// in the response, and are left at their zero value.
+	// A field of an array element is only missing when
+	// no element has it.
+	Missing []string
+
+	// The number of responses merged into the report.
+	// Always 1 for the reports passed to OnDrift.
+	Responses int
+}
+
+// Collects drift reports merged per operation. Use its
+// Record method as StrictDecodingOptions.OnDrift. It is
+// safe for concurrent use.
+//
+//	var drift rapididentity.DriftCollector
+//	client, err := rapididentity.New(rapididentity.Options{
+//		BaseUrl:         baseUrl,
+//		ServiceIdentity: key,
+//		StrictDecoding:  &rapididentity.StrictDecodingOptions{OnDrift: drift.Record},
+//	})
+//	...
+//	for _, report := range drift.Reports() {
+//		log.Printf("%s: extra %v, missing %v", report.Operation, report.Extra, report.Missing)
+//	}
+type DriftCollector struct {
+	mu      sync.Mutex
+	reports map[string]*DriftReport
+}
+
+// Merges the report with the previous reports of the
+// operation. Extra and Missing become the union of the
+// fields of the reports.
+func (dc *DriftCollector) Record(report DriftReport) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.reports == nil {
+		dc.reports = map[string]*DriftReport{}
+	}
+	merged, ok := dc.reports[report.Operation]
+	if !ok {
+		merged = &DriftReport{Operation: report.Operation, OutputType: report.OutputType}
+		dc.reports[report.Operation] = merged
+	}
+	merged.Extra = union(merged.Extra, report.Extra)
+	merged.Missing = union(merged.Missing, report.Missing)
+	merged.Responses += max(report.Responses, 1)
+}
+
+// Returns the merged reports sorted by operation.
+func (dc *DriftCollector) Reports() []DriftReport {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	reports := make([]DriftReport, 0, len(dc.reports))
+	for _, report := range dc.reports {
+		merged := *report
+		merged.Extra = slices.Clone(report.Extra)
+		merged.Missing = slices.Clone(report.Missing)
+		reports = append(reports, merged)
+	}
+	slices.SortFunc(reports, func(a, b DriftReport) int {
+		return cmp.Compare(a.Operation, b.Operation)
+	})
+	return reports
+}
+
+// Returns the sorted union of two sorted lists.
+func union(a []string, b []string) []string {
+	merged := slices.Concat(a, b)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
+
+// Compares the response body of the call with its output
+// type and reports any drift. Raw outputs are skipped.
+func (c *Client) detectDrift(ctx context.Context, call *Call) {
+	if c.strictDecoding == nil {
+		return
+	}
+	if _, ok := call.Output.(*[]byte); ok {
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(call.ResponseBody))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return
+	}
+
+	outputType := reflect.TypeOf(call.Output)
+	for outputType.Kind() == reflect.Pointer {
+		outputType = outputType.Elem()
+	}
+	walker := driftWalker{declared: map[string]bool{}, seen: map[string]bool{}, extra: map[string]bool{}}
+	walker.walk(outputType, value, "")
+
+	var missing []string
+	for path := range walker.declared {
+		if !walker.seen[path] {
+			missing = append(missing, path)
+		}
+	}
+	if len(walker.extra) == 0 && len(missing) == 0 {
+		return
+	}
+	slices.Sort(missing)
+	report := DriftReport{
+		Operation:  call.Operation.String(),
+		OutputType: outputType.String(),
+		Extra:      slices.Sorted(maps.Keys(walker.extra)),
+		Missing:    missing,
+		Responses:  1,
+	}
+
+	if c.logger != nil {
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "rapididentity response drift",
+			slog.String("operation", call.Operation.Name),
+			slog.Any("extra", report.Extra),
+			slog.Any("missing", report.Missing),
+		)
+	}
+	if c.strictDecoding.OnDrift != nil {
+		c.strictDecoding.OnDrift(report)
+	}
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// Walks a decoded JSON value along the Go type it is
+// decoded into, recording the JSON field paths.
+type driftWalker struct {
+	// The fields of the structs reached.
+	declared map[string]bool
+
+	// The declared fields present in the value.
+	seen map[string]bool
+
+	// The fields of the value that are not declared.
+	extra map[string]bool
+}
+
+func (dw *driftWalker) walk(t reflect.Type, value any, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for _, field := range fields {
+			dw.declared[joinPath(path, field.name)] = true
+		}
+		for key, fieldValue := range object {
+			field, ok := matchField(fields, key)
+			if !ok {
+				dw.extra[joinPath(path, key)] = true
+				continue
+			}
+			fieldPath := joinPath(path, field.name)
+			dw.seen[fieldPath] = true
+			dw.walk(field.typ, fieldValue, fieldPath)
+		}
+	case reflect.Slice, reflect.Array:
+		elements, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for _, element := range elements {
+			dw.walk(t.Elem(), element, path+"[]")
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for _, mapValue := range object {
+			dw.walk(t.Elem(), mapValue, joinPath(path, "*"))
+		}
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+// Returns the JSON fields of a struct type following the
+// encoding/json rules: exported fields named by their
+// json tag, skipping "-", with the fields of untagged
+// embedded structs promoted.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embedded)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{name: name, typ: field.Type})
+	}
+	return fields
+}
+
+// Finds the field of the key, preferring an exact match
+// and falling back to the case-insensitive match of
+// encoding/json.
+func matchField(fields []jsonField, key string) (jsonField, bool) {
+	for _, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	return jsonField{}, false
+}
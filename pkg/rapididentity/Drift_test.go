@@ -0,0 +1,114 @@
+package rapididentity
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func setupDrift(t *testing.T, drift *DriftCollector) (*Client, *http.ServeMux) {
+	t.Helper()
+	return setupWithOptions(t, Options{
+		StrictDecoding: &StrictDecodingOptions{OnDrift: drift.Record},
+	})
+}
+
+func TestStrictDecoding(t *testing.T) {
+	t.Parallel()
+	var drift DriftCollector
+	client, mux := setupDrift(t, &drift)
+	mux.HandleFunc(baseUrlPath+"/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"version": 2,
+			"projects": [
+				{"name": "sec_mgr", "owner": "admin"},
+				{"name": "sync", "id": "2"}
+			]
+		}`)
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/connect/fileContent/data.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"anything": true}`)
+	})
+	ctx := context.Background()
+
+	for range 2 {
+		output, err := client.GetConnectProjects(ctx)
+		if err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+		if got, want := len(output.Projects), 2; got != want {
+			t.Errorf("projects: got %d, want %d", got, want)
+		}
+	}
+	if _, err := client.GetConnectFileContent(ctx, GetConnectFileContentInput{Path: "data.json"}); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	reports := drift.Reports()
+	if len(reports) != 1 {
+		t.Fatalf("reports: got %+v, want one", reports)
+	}
+	report := reports[0]
+	if report.Operation != "GET /admin/connect/projects" || report.OutputType != "rapididentity.GetConnectProjectsOutput" || report.Responses != 2 {
+		t.Errorf("report: got %+v", report)
+	}
+	if got, want := fmt.Sprint(report.Extra), "[projects[].owner version]"; got != want {
+		t.Errorf("extra: got %s, want %s", got, want)
+	}
+	if !slices.Contains(report.Missing, "projects[].auditorGroupDN") || !slices.Contains(report.Missing, "projects[].restPoints") {
+		t.Errorf("missing: got %v, want projects[].auditorGroupDN and projects[].restPoints", report.Missing)
+	}
+	for _, present := range []string{"projects", "projects[].name", "projects[].id"} {
+		if slices.Contains(report.Missing, present) {
+			t.Errorf("missing: got %v, want it without %s", report.Missing, present)
+		}
+	}
+}
+
+func TestDriftWalker(t *testing.T) {
+	t.Parallel()
+	type Base struct {
+		Id string `json:"id"`
+	}
+	type Item struct {
+		Base
+		Name     string                  `json:"name"`
+		Secret   string                  `json:"-"`
+		Created  time.Time               `json:"created"`
+		Labels   map[string]Base         `json:"labels"`
+		Policies []*AuthenticationPolicy `json:"policies"`
+		internal string
+	}
+	value := map[string]any{
+		"ID":      "1",
+		"Name":    "a",
+		"secret":  "s",
+		"created": map[string]any{"ignored": true},
+		"labels": map[string]any{
+			"x": map[string]any{"id": "2", "color": "red"},
+		},
+		"policies": []any{map[string]any{"type": "password"}},
+	}
+
+	walker := driftWalker{declared: map[string]bool{}, seen: map[string]bool{}, extra: map[string]bool{}}
+	walker.walk(reflect.TypeFor[Item](), value, "")
+
+	var missing []string
+	for path := range walker.declared {
+		if !walker.seen[path] {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) != 0 {
+		t.Errorf("missing: got %v, want none", missing)
+	}
+	extra := slices.Sorted(maps.Keys(walker.extra))
+	if got, want := fmt.Sprint(extra), "[labels.*.color secret]"; got != want {
+		t.Errorf("extra: got %s, want %s", got, want)
+	}
+}
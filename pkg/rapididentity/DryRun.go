@@ -0,0 +1,119 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// Matched, with errors.Is, by the error returned for
+// mutating operations that were not sent in dry-run mode.
+var ErrDryRun = errors.New("rapididentity: dry run")
+
+// The HTTP request a mutating operation would have sent.
+type DryRunPlan struct {
+	// The operation that was not sent.
+	Operation Operation
+
+	// The HTTP method of the request.
+	Method string
+
+	// The full request URL including the query.
+	Url string
+
+	// The request headers with secrets redacted.
+	Header http.Header
+
+	// The request body with secrets redacted,
+	// such as passwords and the argument values
+	// of sensitive Connect action sets.
+	Body string
+}
+
+// Returned by mutating operations in dry-run mode
+// instead of sending the request.
+//
+//	_, err := client.SaveConnectAction(ctx, input)
+//	var dryRun *rapididentity.DryRunError
+//	if errors.As(err, &dryRun) {
+//		fmt.Println(dryRun.Plan.Method, dryRun.Plan.Url, dryRun.Plan.Body)
+//	}
+type DryRunError struct {
+	Plan DryRunPlan
+}
+
+func (e *DryRunError) Error() string {
+	return "rapididentity: dry run of " + e.Plan.Operation.Name + ": " + e.Plan.Method + " " + e.Plan.Url
+}
+
+// Reports whether the target is ErrDryRun.
+func (e *DryRunError) Is(target error) bool {
+	return target == ErrDryRun
+}
+
+type dryRunKey struct{}
+
+// Returns a context overriding Options.DryRun for
+// the calls made with it.
+func WithDryRun(ctx context.Context, dryRun bool) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, dryRun)
+}
+
+// Reports whether calls made with the
+// context are made in dry-run mode.
+func (c *Client) isDryRun(ctx context.Context) bool {
+	if dryRun, ok := ctx.Value(dryRunKey{}).(bool); ok {
+		return dryRun
+	}
+	return c.dryRun
+}
+
+// Builds the plan of the request of the call without
+// consuming the request body.
+func newDryRunPlan(call *Call) (DryRunPlan, error) {
+	req := call.Request
+	plan := DryRunPlan{
+		Operation: call.Operation,
+		Method:    req.Method,
+		Url:       req.URL.String(),
+		Header:    RedactHeaders(req.Header),
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return plan, nil
+	}
+
+	body := req.Body
+	if req.GetBody != nil {
+		var err error
+		body, err = req.GetBody()
+		if err != nil {
+			return DryRunPlan{}, err
+		}
+	}
+	defer body.Close()
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return DryRunPlan{}, err
+	}
+	plan.Body = RedactRequestBody(req, payload)
+
+	return plan, nil
+}
+
+// Returns the dry-run error of the mutating call.
+func (c *Client) skipDryRun(ctx context.Context, call *Call) error {
+	plan, err := newDryRunPlan(call)
+	if err != nil {
+		return err
+	}
+	if c.logger != nil {
+		c.logger.LogAttrs(ctx, slog.LevelInfo, "rapididentity dry run",
+			slog.String("operation", plan.Operation.Name),
+			slog.String("method", plan.Method),
+			slog.String("url", plan.Url),
+		)
+	}
+	return &DryRunError{Plan: plan}
+}
@@ -0,0 +1,177 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func setupDryRun(t *testing.T, dryRun bool) (*Client, *http.ServeMux, *atomic.Int32) {
+	t.Helper()
+	mux := http.NewServeMux()
+	var requests atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		mux.ServeHTTP(w, r)
+	}), Options{DryRun: dryRun})
+
+	return client, mux, &requests
+}
+
+func TestDryRunMutatingOperations(t *testing.T) {
+	t.Parallel()
+	client, _, requests := setupDryRun(t, true)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		call     func() error
+		method   string
+		url      string
+		body     string
+		excluded string
+	}{
+		{
+			name: "SaveConnectAction",
+			call: func() error {
+				_, err := client.SaveConnectAction(ctx, SaveConnectActionInput{Action: ActionDef{Id: "1234", Name: "SyncUsers", Version: 2}})
+				return err
+			},
+			method: "POST",
+			url:    "/api/rest/admin/connect/actions",
+			body:   `"name":"SyncUsers"`,
+		},
+		{
+			name: "DeleteConnectActionById",
+			call: func() error {
+				_, err := client.DeleteConnectActionById(ctx, DeleteConnectActionByIdInput{Id: "sec_mgr.SyncUsers"})
+				return err
+			},
+			method: "DELETE",
+			url:    "/api/rest/admin/connect/actions/sec_mgr.SyncUsers",
+		},
+		{
+			name: "SetPassword",
+			call: func() error {
+				_, err := client.SetPassword(ctx, SetPasswordInput{Targets: StringList{"08b5f0ec"}, NewPassword: mockPassword})
+				return err
+			},
+			method:   "POST",
+			url:      "/api/rest/profiles/actions/password",
+			body:     `"targets":["08b5f0ec"]`,
+			excluded: mockPassword,
+		},
+		{
+			name: "RunConnectAction",
+			call: func() error {
+				_, err := client.RunConnectAction(ctx, RunConnectActionInput{Action: ConnectAction{Name: "SyncUsers", Args: ArgDefList{{Name: "secret", Value: "hunter2"}}}})
+				return err
+			},
+			method:   "POST",
+			url:      "/api/rest/admin/connect/run",
+			body:     `"name":"SyncUsers"`,
+			excluded: "hunter2",
+		},
+		{
+			name: "DoCustomRequest",
+			call: func() error {
+				_, err := client.DoCustomRequest(ctx, "PUT", "admin/workflow/resources", strings.NewReader(`{"id": "1"}`))
+				return err
+			},
+			method: "PUT",
+			url:    "/api/rest/admin/workflow/resources",
+		},
+	}
+
+	for _, tc := range tests {
+		err := tc.call()
+		if !errors.Is(err, ErrDryRun) {
+			t.Errorf("%s: got error %v, want ErrDryRun", tc.name, err)
+			continue
+		}
+		var dryRun *DryRunError
+		if !errors.As(err, &dryRun) {
+			t.Fatalf("%s: got error %T, want *DryRunError", tc.name, err)
+		}
+		plan := dryRun.Plan
+		if plan.Method != tc.method || !strings.HasSuffix(plan.Url, tc.url) {
+			t.Errorf("%s: got %s %s, want %s %s", tc.name, plan.Method, plan.Url, tc.method, tc.url)
+		}
+		if !strings.Contains(plan.Body, tc.body) {
+			t.Errorf("%s: body %s does not contain %s", tc.name, plan.Body, tc.body)
+		}
+		if tc.excluded != "" && strings.Contains(plan.Body, tc.excluded) {
+			t.Errorf("%s: body %s contains secret", tc.name, plan.Body)
+		}
+		if got := plan.Header.Get("Authorization"); strings.Contains(got, mockServiceIdentity) {
+			t.Errorf("%s: authorization header not redacted: %s", tc.name, got)
+		}
+	}
+
+	if got := requests.Load(); got != 0 {
+		t.Errorf("requests sent: got %d, want 0", got)
+	}
+}
+
+func TestDryRunReadOperations(t *testing.T) {
+	t.Parallel()
+	client, mux, requests := setupDryRun(t, true)
+	mux.HandleFunc(baseUrlPath+"/reporting/auditQuery", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"auditLogRecords": []}`)
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/connect/actions/1234", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id": "1234"}`)
+	})
+
+	ctx := context.Background()
+	if _, err := client.RunAuditReport(ctx, RunAuditReportInput{}); err != nil {
+		t.Errorf("RunAuditReport: got error %s, want none", err)
+	}
+	if _, err := client.GetConnectActionById(ctx, GetConnectActionByIdInput{Id: "1234"}); err != nil {
+		t.Errorf("GetConnectActionById: got error %s, want none", err)
+	}
+	if got, want := requests.Load(), int32(2); got != want {
+		t.Errorf("requests sent: got %d, want %d", got, want)
+	}
+}
+
+func TestWithDryRun(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		option     bool
+		ctx        context.Context
+		wantDryRun bool
+	}{
+		{"option", true, context.Background(), true},
+		{"context enables", false, WithDryRun(context.Background(), true), true},
+		{"context disables", true, WithDryRun(context.Background(), false), false},
+		{"neither", false, context.Background(), false},
+	}
+
+	for _, tc := range tests {
+		client, mux, requests := setupDryRun(t, tc.option)
+		mux.HandleFunc(baseUrlPath+"/admin/connect/actions/1234", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{"success": true}`)
+		})
+
+		_, err := client.DeleteConnectActionById(tc.ctx, DeleteConnectActionByIdInput{Id: "1234"})
+		if got := errors.Is(err, ErrDryRun); got != tc.wantDryRun {
+			t.Errorf("%s: got error %v, want dry run %t", tc.name, err, tc.wantDryRun)
+		}
+		wantRequests := int32(1)
+		if tc.wantDryRun {
+			wantRequests = 0
+		}
+		if got := requests.Load(); got != wantRequests {
+			t.Errorf("%s: requests sent: got %d, want %d", tc.name, got, wantRequests)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package rapididentity
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Builds the request URL for the path relative to
+// /api/rest with the encoded query. Values placed in
+// the path must be escaped with url.PathEscape or
+// escapeFilePath.
+func (c *Client) endpoint(path string, query url.Values) string {
+	endpointUrl := c.baseEndpoint + path
+	if len(query) > 0 {
+		endpointUrl += "?" + query.Encode()
+	}
+	return endpointUrl
+}
+
+// Escapes a slash separated Connect file path. Each
+// segment is escaped on its own so the slashes remain
+// path separators while characters such as spaces,
+// '#' and '?' can not break the request URL.
+func escapeFilePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Sets the Connect project query parameter. An empty
+// project is omitted so all projects are used, and
+// MainProject is sent as an empty value.
+func setProject(query url.Values, project string) {
+	switch project {
+	case "":
+	case MainProject:
+		query.Set("project", "")
+	default:
+		query.Set("project", project)
+	}
+}
@@ -0,0 +1,222 @@
+package rapididentity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+var hostileNames = []string{
+	"sec_mgr",
+	"project with spaces",
+	"project#fragment",
+	"project&extra=param",
+	"project?query",
+	"100% done",
+	"plus+sign",
+	"semi;colon",
+	"ünïcödé",
+}
+
+var hostileFilePaths = []string{
+	"log/jobs",
+	"dir with spaces/file name.txt",
+	"exports/report#1.csv",
+	"a&b=c/d?e.txt",
+	"percent/100%.log",
+	"plus+sign/semi;colon.txt",
+}
+
+func TestEndpointEscapesConnectFiles(t *testing.T) {
+	t.Parallel()
+	for _, project := range hostileNames {
+		for _, path := range hostileFilePaths {
+			t.Run(project+" "+path, func(t *testing.T) {
+				t.Parallel()
+				client, mux := setup(t)
+				mux.HandleFunc(baseUrlPath+"/admin/connect/files/{filePath...}", func(w http.ResponseWriter, r *http.Request) {
+					testQueryParam(t, r, "project", project)
+					if got := r.PathValue("filePath"); got != path {
+						t.Errorf("request path: got %s, want %s", got, path)
+					}
+					w.WriteHeader(http.StatusOK)
+					fmt.Fprint(w, `{}`)
+				})
+
+				input := GetConnectFilesInput{
+					Path:    path,
+					Project: project,
+				}
+				if _, err := client.GetConnectFiles(context.Background(), input); err != nil {
+					t.Errorf("got error %s, want none", err)
+				}
+			})
+		}
+	}
+}
+
+func TestEndpointEscapesConnectFileContent(t *testing.T) {
+	t.Parallel()
+	for _, path := range hostileFilePaths {
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+			client, mux := setup(t)
+			mux.HandleFunc(baseUrlPath+"/admin/connect/fileContent/{filePath...}", func(w http.ResponseWriter, r *http.Request) {
+				testQueryParam(t, r, "project", "project with spaces")
+				testQueryParam(t, r, "decompress", "true")
+				if got := r.PathValue("filePath"); got != path {
+					t.Errorf("request path: got %s, want %s", got, path)
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			input := GetConnectFileContentInput{
+				Path:       path,
+				Project:    "project with spaces",
+				Decompress: true,
+			}
+			if _, err := client.GetConnectFileContent(context.Background(), input); err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+		})
+	}
+}
+
+func TestEndpointEscapesConnectFileContentZip(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/connect/fileContentZip", func(w http.ResponseWriter, r *http.Request) {
+		testQueryParam(t, r, "project", "project&extra=param")
+		if got := r.URL.Query()["path"]; !slices.Equal(got, hostileFilePaths) {
+			t.Errorf("request paths: got %q, want %q", got, hostileFilePaths)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	input := GetConnectFileContentZipInput{
+		Project:  "project&extra=param",
+		PathList: hostileFilePaths,
+	}
+	if _, err := client.GetConnectFileContentZip(context.Background(), input); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
+
+func TestEndpointEscapesConnectActionId(t *testing.T) {
+	t.Parallel()
+	for _, name := range hostileNames {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			client, mux := setup(t)
+			id := name + ".action/name"
+			mux.HandleFunc(baseUrlPath+"/admin/connect/actions/{nameOrId}", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					testQueryParam(t, r, "metaDataOnly", "true")
+				}
+				if got := r.PathValue("nameOrId"); got != id {
+					t.Errorf("request id: got %s, want %s", got, id)
+				}
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"success": true}`)
+			})
+
+			ctx := context.Background()
+			if _, err := client.GetConnectActionById(ctx, GetConnectActionByIdInput{Id: id, MetaDataOnly: true}); err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+			if _, err := client.DeleteConnectActionById(ctx, DeleteConnectActionByIdInput{Id: id}); err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+		})
+	}
+}
+
+func TestEndpointEscapesProjectQuery(t *testing.T) {
+	t.Parallel()
+	for _, project := range append(hostileNames, MainProject) {
+		t.Run(project, func(t *testing.T) {
+			t.Parallel()
+			want := project
+			if project == MainProject {
+				want = ""
+			}
+			client, mux := setup(t)
+			mux.HandleFunc(baseUrlPath+"/admin/connect/jobs", func(w http.ResponseWriter, r *http.Request) {
+				if !r.URL.Query().Has("project") {
+					t.Error("request query param project is missing")
+				}
+				testQueryParam(t, r, "project", want)
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{}`)
+			})
+			mux.HandleFunc(baseUrlPath+"/admin/connect/search/actions", func(w http.ResponseWriter, r *http.Request) {
+				testQueryParam(t, r, "project", want)
+				testQueryParam(t, r, "searchString", project)
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{}`)
+			})
+
+			ctx := context.Background()
+			if _, err := client.GetConnectJobs(ctx, GetConnectJobsInput{Project: project}); err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+			input := SearchConnectActionSetsInput{
+				Project:      project,
+				SearchString: project,
+			}
+			if _, err := client.SearchConnectActionSets(ctx, input); err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+		})
+	}
+}
+
+func TestEndpointEscapesUserIds(t *testing.T) {
+	t.Parallel()
+	dn := "idautoID=1234,ou=People,dc=example,dc=com"
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/ldap/users/{dnOrId}", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.PathValue("dnOrId"); got != dn {
+			t.Errorf("request dn: got %s, want %s", got, dn)
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc(baseUrlPath+"/users", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query()["did"]; !slices.Equal(got, hostileNames) {
+			t.Errorf("request delegation ids: got %q, want %q", got, hostileNames)
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `[]`)
+	})
+
+	ctx := context.Background()
+	if _, err := client.GetUserById(ctx, GetUserByIdInput{Id: dn}); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+	if _, err := client.RunUserQuery(ctx, RunUserQueryInput{DelegationIds: hostileNames}); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
+
+func TestEndpointEscapesPageToken(t *testing.T) {
+	t.Parallel()
+	token := "abc+def/ghi=&jkl"
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/reporting/auditQuery", func(w http.ResponseWriter, r *http.Request) {
+		testQueryParam(t, r, "page_token", token)
+		testQueryParam(t, r, "page_size", "50")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	input := RunAuditReportInput{
+		PageSize:  50,
+		PageToken: token,
+	}
+	if _, err := client.RunAuditReport(context.Background(), input); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
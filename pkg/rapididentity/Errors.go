@@ -0,0 +1,142 @@
+package rapididentity
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// Sentinel errors for use with errors.Is.
+//
+//	_, err := client.GetUserById(ctx, input)
+//	if errors.Is(err, rapididentity.ErrNotFound) {
+//		// the user does not exist
+//	}
+var (
+	// The request was not authorized (401). The service
+	// identity key or the session token is invalid or
+	// has expired.
+	ErrUnauthorized = errors.New("rapididentity: unauthorized")
+
+	// The caller does not have permission for the
+	// request (403).
+	ErrForbidden = errors.New("rapididentity: forbidden")
+
+	// The requested resource does not exist (404).
+	ErrNotFound = errors.New("rapididentity: not found")
+
+	// The resource was modified since it was read (409).
+	// For SaveConnectAction query the action again and
+	// use the returned version.
+	ErrVersionConflict = errors.New("rapididentity: version conflict")
+
+	// The tenant throttled the request (429).
+	ErrRateLimited = errors.New("rapididentity: rate limited")
+
+	// The response body could not be decoded
+	// into the output of the operation.
+	ErrDecode = errors.New("rapididentity: unable to decode response")
+
+	// The call was made after Close.
+	ErrClientClosed = errors.New("rapididentity: client is closed")
+)
+
+// Error message to be used for additional
+// information for all endpoints
+type RapidIdentityError struct {
+	Method  string
+	ReqUrl  *url.URL
+	Message string
+	Reason  string
+	Code    int
+
+	// The structured JSON error payload returned
+	// by the server. Nil if the response body is
+	// not a JSON error payload.
+	Payload *ErrorPayload
+
+	// The underlying cause such as a read or decode
+	// error. Nil for unsuccessful status codes.
+	Err error
+}
+
+func (re RapidIdentityError) Error() string {
+	return re.Message
+}
+
+// Returns the underlying cause of the error.
+func (re RapidIdentityError) Unwrap() error {
+	return re.Err
+}
+
+// Reports whether the error matches one of the
+// sentinel errors based on the status code.
+func (re RapidIdentityError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return re.Code == http.StatusUnauthorized
+	case ErrForbidden:
+		return re.Code == http.StatusForbidden
+	case ErrNotFound:
+		return re.Code == http.StatusNotFound
+	case ErrVersionConflict:
+		return re.Code == http.StatusConflict
+	case ErrRateLimited:
+		return re.Code == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// JSON error payload returned by the
+// RapidIdentity REST API.
+type ErrorPayload struct {
+	// The error message.
+	Message string `json:"message"`
+
+	// The error type or short description.
+	Error string `json:"error"`
+
+	// The http status code reported by the server.
+	HttpStatus int `json:"httpStatus"`
+
+	// Additional details on the error.
+	Details string `json:"details"`
+}
+
+// Parses the JSON error payload from the response
+// body. Returns nil if the body is not a JSON object
+// with at least one of the payload fields.
+func parseErrorPayload(resBody []byte) *ErrorPayload {
+	var payload ErrorPayload
+	if err := json.Unmarshal(resBody, &payload); err != nil {
+		return nil
+	}
+	if payload == (ErrorPayload{}) {
+		return nil
+	}
+	return &payload
+}
+
+// Creates the error for an unsuccessful status code. The
+// reason is replaced by the message of the JSON error
+// payload when present.
+func newStatusError(req *http.Request, code int, resBody []byte, reason string) RapidIdentityError {
+	payload := parseErrorPayload(resBody)
+	if payload != nil {
+		switch {
+		case payload.Message != "":
+			reason = payload.Message
+		case payload.Error != "":
+			reason = payload.Error
+		}
+	}
+	return RapidIdentityError{
+		Method:  req.Method,
+		ReqUrl:  req.URL,
+		Message: string(resBody),
+		Reason:  reason,
+		Code:    code,
+		Payload: payload,
+	}
+}
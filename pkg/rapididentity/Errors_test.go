@@ -0,0 +1,118 @@
+package rapididentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorsIs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status int
+		want   error
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized, want: ErrUnauthorized},
+		{name: "forbidden", status: http.StatusForbidden, want: ErrForbidden},
+		{name: "not found", status: http.StatusNotFound, want: ErrNotFound},
+		{name: "version conflict", status: http.StatusConflict, want: ErrVersionConflict},
+		{name: "rate limited", status: http.StatusTooManyRequests, want: ErrRateLimited},
+	}
+
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrVersionConflict, ErrRateLimited, ErrDecode}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client, mux := setup(t)
+			mux.HandleFunc(baseUrlPath+"/admin/connect/actions", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+
+			_, err := client.SaveConnectAction(context.Background(), SaveConnectActionInput{})
+			for _, sentinel := range sentinels {
+				if got, want := errors.Is(err, sentinel), sentinel == tt.want; got != want {
+					t.Errorf("errors.Is(%v, %v): got %t, want %t", err, sentinel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorPayload(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/connect/actions/{nameOrId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "action not found", "httpStatus": 404}`)
+	})
+
+	_, err := client.GetConnectActionById(context.Background(), GetConnectActionByIdInput{Id: "1234"})
+	var riError RapidIdentityError
+	if !errors.As(err, &riError) {
+		t.Fatalf("got error %v, want RapidIdentityError", err)
+	}
+
+	if riError.Payload == nil {
+		t.Fatal("got nil payload, want one")
+	}
+	if got, want := riError.Payload.HttpStatus, http.StatusNotFound; got != want {
+		t.Errorf("payload http status: got %d, want %d", got, want)
+	}
+	if got, want := riError.Reason, "action not found"; got != want {
+		t.Errorf("reason: got %s, want %s", got, want)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("errors.Is(%v, ErrNotFound): got false, want true", err)
+	}
+}
+
+func TestErrorPayloadNotJSON(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "internal server error")
+	})
+
+	_, err := client.GetBootstrapInfo(context.Background())
+	var riError RapidIdentityError
+	if !errors.As(err, &riError) {
+		t.Fatalf("got error %v, want RapidIdentityError", err)
+	}
+
+	if riError.Payload != nil {
+		t.Errorf("payload: got %+v, want nil", riError.Payload)
+	}
+	if got, want := riError.Reason, "internal server error"; got != want {
+		t.Errorf("reason: got %s, want %s", got, want)
+	}
+}
+
+func TestErrorDecode(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/ldap/users/{dnOrId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id": 1234}`)
+	})
+
+	_, err := client.GetUserById(context.Background(), GetUserByIdInput{Id: "1234"})
+	if !errors.Is(err, ErrDecode) {
+		t.Fatalf("errors.Is(%v, ErrDecode): got false, want true", err)
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if !errors.As(err, &typeError) {
+		t.Errorf("errors.As(%v, *json.UnmarshalTypeError): got false, want true", err)
+	}
+	var riError RapidIdentityError
+	if !errors.As(err, &riError) || riError.Code != http.StatusOK {
+		t.Errorf("got error %v, want RapidIdentityError with status %d", err, http.StatusOK)
+	}
+}
@@ -0,0 +1,8 @@
+package rapididentity
+
+// Checks the //meta:operation annotated methods against a local
+// copy of the OpenAPI spec given by $RI_OPENAPI_SPEC, and lists
+// the operations not yet wrapped. Skipped when it is not set.
+// See tools/openapi to scaffold a method for an operation.
+//
+//go:generate go run ../../tools/openapi check -spec=$RI_OPENAPI_SPEC
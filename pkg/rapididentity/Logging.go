@@ -0,0 +1,212 @@
+package rapididentity
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// Replacement for redacted values in logs.
+	redacted = "[REDACTED]"
+
+	// The maximum number of body bytes logged
+	// at debug level.
+	maxLoggedBodySize = 64 << 10
+)
+
+// JSON keys whose values are always redacted
+// from logged bodies.
+var secretKeys = map[string]bool{
+	"password":    true,
+	"newPassword": true,
+	"token":       true,
+	"answer":      true,
+}
+
+// JSON keys whose values are redacted from the request
+// bodies of the authentication flow, such as TOTP and
+// email codes, in addition to the secretKeys.
+var authnSecretKeys = map[string]bool{
+	"password":    true,
+	"newPassword": true,
+	"token":       true,
+	"answer":      true,
+	"code":        true,
+}
+
+// Headers whose values are redacted from logs.
+var secretHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// Logs the redacted RapidIdentity user
+// without the password.
+func (u RapidIdentityUser) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("username", u.Username),
+		slog.String("password", redacted),
+	)
+}
+
+// Logs the redacted password change
+// without the new password.
+func (sp SetPasswordInput) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("isSelfService", sp.IsSelfService),
+		slog.String("delegationId", sp.DelegationId),
+		slog.Bool("mustUpdate", sp.MustUpdate),
+		slog.Any("targets", []string(sp.Targets)),
+		slog.String("newPassword", redacted),
+	)
+}
+
+// Sends the HTTP request and logs the method, operation,
+// URL, status and latency. Redacted headers and bodies are
+// logged at debug level.
+func (c *Client) logRoundTrip(op Operation, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	debug := c.logger.Enabled(ctx, slog.LevelDebug)
+
+	var reqBody []byte
+	if debug && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(body, maxLoggedBodySize))
+			body.Close()
+		}
+	}
+
+	start := time.Now()
+	res, err := c.httpClient.Do(req)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("operation", op.Name),
+		slog.String("url", req.URL.String()),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		c.logger.LogAttrs(ctx, slog.LevelError, "rapididentity request failed", attrs...)
+		return res, err
+	}
+
+	attrs = append(attrs, slog.Int("status", res.StatusCode))
+	level := slog.LevelInfo
+	if res.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	c.logger.LogAttrs(ctx, level, "rapididentity request", attrs...)
+
+	if debug {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, maxLoggedBodySize))
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(resBody), res.Body), res.Body}
+
+		attrs = append(attrs,
+			slog.Any("requestHeaders", RedactHeaders(req.Header)),
+			slog.String("requestBody", RedactRequestBody(req, reqBody)),
+			slog.Any("responseHeaders", RedactHeaders(res.Header)),
+			slog.String("responseBody", RedactBody(resBody)),
+		)
+		c.logger.LogAttrs(ctx, slog.LevelDebug, "rapididentity request bodies", attrs...)
+	}
+
+	return res, nil
+}
+
+// Returns a copy of the headers with the secret
+// header values, such as Authorization, redacted.
+func RedactHeaders(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range secretHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, redacted)
+		}
+	}
+	return header
+}
+
+// Redacts secrets from a request or response body the
+// same way as the debug logs. JSON bodies have the values
+// of secret keys, such as password and token, and the
+// argument values of sensitive Connect action sets
+// redacted. JSON that can not be parsed, such as a
+// truncated body, is omitted. Use RedactRequestBody for
+// request bodies.
+func RedactBody(body []byte) string {
+	return redactBody(body, false, secretKeys)
+}
+
+// Redacts secrets from the body of the request like
+// RedactBody. The argument values of the actions run with
+// RunConnectAction are redacted as well, since the request
+// does not tell whether the action set is sensitive, and so
+// are the codes sent to the authentication flow.
+func RedactRequestBody(req *http.Request, body []byte) string {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case req.Method == opRunConnectAction.Method && strings.HasSuffix(path, "/api/rest"+opRunConnectAction.Path):
+		return redactBody(body, true, secretKeys)
+	case strings.Contains(path, "/api/rest/authn/"):
+		return redactBody(body, false, authnSecretKeys)
+	}
+	return redactBody(body, false, secretKeys)
+}
+
+func redactBody(body []byte, sensitive bool, keys map[string]bool) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return ""
+	}
+	if trimmed[0] != '{' && trimmed[0] != '[' {
+		return string(body)
+	}
+
+	var value any
+	if err := json.Unmarshal(trimmed, &value); err != nil {
+		return "[OMITTED UNPARSABLE JSON]"
+	}
+	redactedBody, err := json.Marshal(redactValue(value, sensitive, keys))
+	if err != nil {
+		return "[OMITTED UNPARSABLE JSON]"
+	}
+	return string(redactedBody)
+}
+
+// Walks a decoded JSON value redacting the values of the
+// keys. Within an action set marked sensitive every
+// argument value is redacted.
+func redactValue(value any, sensitive bool, keys map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if s, ok := v["sensitive"].(bool); ok && s {
+			sensitive = true
+		}
+		for key, child := range v {
+			switch {
+			case keys[key]:
+				v[key] = redacted
+			case sensitive && strings.EqualFold(key, "value"):
+				v[key] = redacted
+			default:
+				v[key] = redactValue(child, sensitive, keys)
+			}
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child, sensitive, keys)
+		}
+		return v
+	default:
+		return v
+	}
+}
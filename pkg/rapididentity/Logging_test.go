@@ -0,0 +1,252 @@
+package rapididentity
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func setupLogging(t *testing.T, level slog.Level, options Options) (*Client, *http.ServeMux, *bytes.Buffer) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseUrlPath+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"session": {"id": "1234", "token": "session_token"}}`)
+	})
+	var logs bytes.Buffer
+	options.Logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: level}))
+	client := newTestClient(t, mux, options)
+
+	return client, mux, &logs
+}
+
+func TestLoggingRequest(t *testing.T) {
+	t.Parallel()
+	client, mux, logs := setupLogging(t, slog.LevelInfo, Options{
+		ServiceIdentity: mockServiceIdentity,
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/ldap/users/{dnOrId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id": "1234"}`)
+	})
+
+	if _, err := client.GetUserById(context.Background(), GetUserByIdInput{Id: "1234"}); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	got := logs.String()
+	for _, want := range []string{
+		`"level":"INFO"`,
+		`"method":"GET"`,
+		`"operation":"GetUserById"`,
+		`/api/rest/admin/ldap/users/1234"`,
+		`"status":200`,
+		`"latency":`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("logs %s do not contain %s", got, want)
+		}
+	}
+	if strings.Contains(got, "responseBody") {
+		t.Errorf("logs %s contain bodies at info level", got)
+	}
+}
+
+func TestLoggingRedaction(t *testing.T) {
+	t.Parallel()
+	client, mux, logs := setupLogging(t, slog.LevelDebug, Options{
+		RapidIdentityUser: &RapidIdentityUser{
+			Username: mockUsername,
+			Password: mockPassword,
+		},
+	})
+	defer client.Close()
+	mux.HandleFunc(baseUrlPath+"/profiles/actions/password", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `[{"target": "1234", "success": true}]`)
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/connect/actions", func(w http.ResponseWriter, r *http.Request) {
+		reqBody, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		w.Write(reqBody)
+	})
+
+	ctx := context.Background()
+	_, err := client.SetPassword(ctx, SetPasswordInput{
+		Targets:     StringList{"1234"},
+		NewPassword: "new_secret_password",
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	_, err = client.SaveConnectAction(ctx, SaveConnectActionInput{
+		Action: ActionDef{
+			Name:      "getApiKey",
+			Sensitive: true,
+			ArgDefs: ArgDefList{
+				{Name: "apiKey", Value: "sensitive_arg_value"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	got := logs.String()
+	for _, secret := range []string{
+		mockPassword,
+		"session_token",
+		"new_secret_password",
+		"sensitive_arg_value",
+	} {
+		if strings.Contains(got, secret) {
+			t.Errorf("logs contain secret %s", secret)
+		}
+	}
+	for _, want := range []string{
+		`"operation":"New"`,
+		`"operation":"SetPassword"`,
+		`\"name\":\"apiKey\"`,
+		`"responseBody":`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("logs %s do not contain %s", got, want)
+		}
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	t.Parallel()
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	logger.Info("input",
+		"user", RapidIdentityUser{Username: mockUsername, Password: mockPassword},
+		"setPassword", SetPasswordInput{NewPassword: "new_secret_password"},
+	)
+
+	got := logs.String()
+	if strings.Contains(got, mockPassword) || strings.Contains(got, "new_secret_password") {
+		t.Errorf("logs %s contain secrets", got)
+	}
+	if !strings.Contains(got, mockUsername) {
+		t.Errorf("logs %s do not contain %s", got, mockUsername)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "empty",
+			body: "",
+			want: "",
+		},
+		{
+			name: "plain text",
+			body: "job log",
+			want: "job log",
+		},
+		{
+			name: "secret keys",
+			body: `{"username":"user","password":"secret"}`,
+			want: `{"password":"[REDACTED]","username":"user"}`,
+		},
+		{
+			name: "sensitive action set",
+			body: `[{"sensitive":true,"argDefs":[{"name":"key","value":"secret"}]},{"sensitive":false,"argDefs":[{"name":"key","value":"visible"}]}]`,
+			want: `[{"argDefs":[{"name":"key","value":"[REDACTED]"}],"sensitive":true},{"argDefs":[{"name":"key","value":"visible"}],"sensitive":false}]`,
+		},
+		{
+			name: "error code",
+			body: `{"code":"E1001","message":"not found"}`,
+			want: `{"code":"E1001","message":"not found"}`,
+		},
+		{
+			name: "truncated json",
+			body: `{"password":"sec`,
+			want: "[OMITTED UNPARSABLE JSON]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := RedactBody([]byte(tt.body)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactRequestBody(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{
+			method: "POST",
+			path:   "/api/rest/admin/connect/run",
+			body:   `{"name":"SetSecret","args":[{"name":"secret","value":"hunter2"}]}`,
+			want:   `{"args":[{"name":"secret","value":"[REDACTED]"}],"name":"SetSecret"}`,
+		},
+		{
+			method: "POST",
+			path:   "/api/rest/authn/v1/totp",
+			body:   `{"id":"authn-2","code":"123456"}`,
+			want:   `{"code":"[REDACTED]","id":"authn-2"}`,
+		},
+		{
+			method: "POST",
+			path:   "/api/rest/admin/connect/actions",
+			body:   `{"code":"sync","args":[{"name":"limit","value":"10"}]}`,
+			want:   `{"args":[{"name":"limit","value":"10"}],"code":"sync"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "https://portal.us001-rapididentity.com"+tt.path, nil)
+		if got := RedactRequestBody(req, []byte(tt.body)); got != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoggingRunConnectActionArgs(t *testing.T) {
+	t.Parallel()
+	client, mux, logs := setupLogging(t, slog.LevelDebug, Options{
+		ServiceIdentity: mockServiceIdentity,
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/connect/run", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	_, err := client.RunConnectAction(context.Background(), RunConnectActionInput{
+		Action: ConnectAction{Name: "SetSecret", Args: ArgDefList{{Name: "secret", Value: "hunter2"}}},
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got := logs.String(); strings.Contains(got, "hunter2") || !strings.Contains(got, "SetSecret") {
+		t.Errorf("logs %s: want the action without the argument value", got)
+	}
+}
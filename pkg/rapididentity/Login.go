@@ -0,0 +1,210 @@
+package rapididentity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+)
+
+// The maximum number of authentication steps
+// before Login gives up.
+const maxLoginSteps = 16
+
+// Returned by Login when the authentication
+// flow ends without a session.
+var ErrLoginIncomplete = errors.New("rapididentity: login did not complete")
+
+// An authentication step of the Login flow
+// that must be answered by the ChallengeResponder.
+type Challenge struct {
+	// The username being authenticated.
+	Username string
+
+	// The authentication method type of the step.
+	// For example password, totp or pictograph.
+	Type string
+
+	// The method configuration from the user's
+	// authentication policy, for example TotpMethod.
+	// Nil if the policy does not contain the method.
+	Method AuthenticationPolicyMethod
+
+	// The step data returned by the server, such as
+	// the images of a pictograph step. May be empty.
+	Data json.RawMessage
+}
+
+// Answers an authentication step with the fields of the
+// step request, for example {"code": "123456"} for totp.
+// Returning an error aborts the login.
+type ChallengeResponder func(ctx context.Context, challenge Challenge) (map[string]any, error)
+
+// Returns a ChallengeResponder answering password steps
+// with the password and every other step with next.
+// If next is nil other steps fail the login.
+func PasswordResponder(password string, next ChallengeResponder) ChallengeResponder {
+	return func(ctx context.Context, challenge Challenge) (map[string]any, error) {
+		if challenge.Type == "password" {
+			return map[string]any{"password": password}, nil
+		}
+		if next == nil {
+			return nil, fmt.Errorf("rapididentity: no response for authentication method %s", challenge.Type)
+		}
+		return next(ctx, challenge)
+	}
+}
+
+// Params for Login function.
+type LoginInput struct {
+	// The username of the user to authenticate.
+	Username string
+
+	// Answers each authentication step
+	// required by the user's policy. Required.
+	Responder ChallengeResponder
+}
+
+// A response of the authentication flow. Completed
+// responses include the session fields.
+type authnStep struct {
+	// The id of the authentication flow.
+	Id string `json:"id"`
+
+	// The next authentication method type.
+	Method string `json:"method"`
+
+	// The data for the next authentication method.
+	Challenge json.RawMessage `json:"challenge"`
+
+	// Whether the authentication is complete.
+	Completed bool `json:"completed"`
+
+	// The authentication policies of the user.
+	// Only returned by the first step.
+	AuthenticationPolicies AuthenticationPolicyList `json:"authenticationPolicies"`
+
+	Session
+}
+
+// Creates a new RapidIdentity Client with a session from
+// the authn/v1 login flow. Unlike RapidIdentityUser, which
+// only supports a username and password, Login walks every
+// method of the user's authentication policy, such as a
+// TOTP code after the password, asking the responder for
+// each step. The ServiceIdentity, RapidIdentityUser and
+// Credentials options are ignored.
+//
+// The session is not renewed when it expires since
+// that would require the responder again.
+//
+//	client, err := rapididentity.Login(ctx, options, rapididentity.LoginInput{
+//		Username: username,
+//		Responder: rapididentity.PasswordResponder(password,
+//			func(ctx context.Context, challenge rapididentity.Challenge) (map[string]any, error) {
+//				return map[string]any{"code": readCode()}, nil
+//			}),
+//	})
+//
+//meta:operation POST /authn/v1/username
+//meta:operation POST /authn/v1/{method}
+func Login(ctx context.Context, options Options, params LoginInput) (*Client, error) {
+	if params.Responder == nil {
+		return nil, errors.New("rapididentity: LoginInput.Responder is required")
+	}
+
+	options.ServiceIdentity = ""
+	options.RapidIdentityUser = nil
+	options.Credentials = nil
+	c, err := New(options)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("authenticationPolicies", "true")
+	step, err := c.authnStep(ctx, opStartLogin, "/authn/v1/username", query, map[string]any{"username": params.Username})
+	if err != nil {
+		return nil, err
+	}
+	policies := step.AuthenticationPolicies
+
+	for range maxLoginSteps {
+		if step.Completed {
+			if step.Session.Session.Token == "" {
+				return nil, fmt.Errorf("%w: no session returned", ErrLoginIncomplete)
+			}
+			session := step.Session
+			c.mu.Lock()
+			c.session = &session
+			c.mu.Unlock()
+			return c, nil
+		}
+		if step.Method == "" {
+			return nil, fmt.Errorf("%w: no authentication method returned", ErrLoginIncomplete)
+		}
+
+		challenge := Challenge{
+			Username: params.Username,
+			Type:     step.Method,
+			Method:   policyMethod(policies, step.Method),
+			Data:     step.Challenge,
+		}
+		fields, err := params.Responder(ctx, challenge)
+		if err != nil {
+			return nil, err
+		}
+
+		body := map[string]any{}
+		maps.Copy(body, fields)
+		body["id"] = step.Id
+		step, err = c.authnStep(ctx, opLoginStep, "/authn/v1/"+url.PathEscape(step.Method), nil, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: more than %d authentication steps", ErrLoginIncomplete, maxLoginSteps)
+}
+
+// Sends an authentication step of the Login flow.
+func (c *Client) authnStep(ctx context.Context, op Operation, path string, query url.Values, body map[string]any) (*authnStep, error) {
+	endpointUrl := c.endpoint(path, query)
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	requestBody := bytes.NewBuffer(payload)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	var output authnStep
+	err = c.invoke(ctx, op, nil, req, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+// Returns the first enabled method of the
+// method type in the authentication policies.
+func policyMethod(policies AuthenticationPolicyList, methodType string) AuthenticationPolicyMethod {
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		for _, method := range policy.Methods {
+			if info := method.GetBaseAuthenticationMethodInfo(); info.Type == methodType && info.Enabled {
+				return method
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package rapididentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// Fake authn/v1 server requiring a password
+// followed by a TOTP code.
+func setupLogin(t *testing.T) (Options, *http.ServeMux) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseUrlPath+"/authn/v1/username", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		testQueryParam(t, r, "authenticationPolicies", "true")
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("request header Authorization: got %s, want none", got)
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if got, want := body["username"], mockUsername; got != want {
+			t.Errorf("request username: got %v, want %s", got, want)
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{
+			"id": "authn-1",
+			"method": "password",
+			"authenticationPolicies": [{
+				"id": "mfa",
+				"name": "MFA",
+				"enabled": true,
+				"methods": [
+					{"type": "password", "enabled": true},
+					{"type": "totp", "enabled": true, "issuerName": "RapidIdentity"}
+				]
+			}]
+		}`)
+	})
+	mux.HandleFunc(baseUrlPath+"/authn/v1/password", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["id"] != "authn-1" || body["password"] != mockPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "invalid password"}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id": "authn-2", "method": "totp", "challenge": {"digits": 6}}`)
+	})
+	mux.HandleFunc(baseUrlPath+"/authn/v1/totp", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["id"] != "authn-2" || body["code"] != "123456" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "invalid code"}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"completed": true, "session": {"id": "1234", "token": "login-token", "user": {"email": "%s"}}}`, mockUsername)
+	})
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer login-token")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	return Options{HTTPClient: &http.Client{}, BaseUrl: newTestServer(t, mux)}, mux
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+	options, _ := setupLogin(t)
+
+	var challenges []Challenge
+	totp := func(ctx context.Context, challenge Challenge) (map[string]any, error) {
+		challenges = append(challenges, challenge)
+		return map[string]any{"code": "123456"}, nil
+	}
+
+	ctx := context.Background()
+	client, err := Login(ctx, options, LoginInput{
+		Username:  mockUsername,
+		Responder: PasswordResponder(mockPassword, totp),
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	if got, want := client.session.Session.User.Email, mockUsername; got != want {
+		t.Errorf("session user: got %s, want %s", got, want)
+	}
+	if len(challenges) != 1 {
+		t.Fatalf("challenges: got %d, want 1", len(challenges))
+	}
+	method, ok := challenges[0].Method.(TotpMethod)
+	if !ok || method.IssuerName != "RapidIdentity" {
+		t.Errorf("challenge method: got %+v, want TotpMethod from policy", challenges[0].Method)
+	}
+	if got, want := string(challenges[0].Data), `{"digits": 6}`; got != want {
+		t.Errorf("challenge data: got %s, want %s", got, want)
+	}
+
+	if _, err := client.GetBootstrapInfo(ctx); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
+
+func TestLoginWrongCode(t *testing.T) {
+	t.Parallel()
+	options, _ := setupLogin(t)
+	totp := func(ctx context.Context, challenge Challenge) (map[string]any, error) {
+		return map[string]any{"code": "000000"}, nil
+	}
+
+	_, err := Login(context.Background(), options, LoginInput{
+		Username:  mockUsername,
+		Responder: PasswordResponder(mockPassword, totp),
+	})
+	var riError RapidIdentityError
+	if !errors.As(err, &riError) || !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("got error %v, want RapidIdentityError matching ErrUnauthorized", err)
+	}
+	if got, want := riError.Reason, "invalid code"; got != want {
+		t.Errorf("reason: got %s, want %s", got, want)
+	}
+}
+
+func TestLoginResponderError(t *testing.T) {
+	t.Parallel()
+	options, _ := setupLogin(t)
+
+	_, err := Login(context.Background(), options, LoginInput{
+		Username:  mockUsername,
+		Responder: PasswordResponder(mockPassword, nil),
+	})
+	if err == nil {
+		t.Fatal("got no error, want error for unanswered totp step")
+	}
+}
+
+func TestLoginWithoutResponder(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	baseUrl := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id": "authn-1", "method": "password"}`)
+	}))
+
+	_, err := Login(context.Background(), Options{HTTPClient: &http.Client{}, BaseUrl: baseUrl}, LoginInput{
+		Username: mockUsername,
+	})
+	if err == nil || !strings.Contains(err.Error(), "Responder is required") {
+		t.Errorf("got error %v, want a missing Responder error", err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("requests: got %d, want none", got)
+	}
+}
+
+func TestLoginIncomplete(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{name: "endless steps", response: `{"id": "authn-loop", "method": "email"}`},
+		{name: "completed without session", response: `{"completed": true}`},
+		{name: "no method", response: `{"id": "authn-2"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mux := http.NewServeMux()
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+			mux.HandleFunc(baseUrlPath+"/authn/v1/username", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"id": "authn-1", "method": "email"}`)
+			})
+			mux.HandleFunc(baseUrlPath+"/authn/v1/email", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, tt.response)
+			})
+
+			baseUrl, _ := url.Parse(server.URL)
+			email := func(ctx context.Context, challenge Challenge) (map[string]any, error) {
+				return map[string]any{"code": "1"}, nil
+			}
+			_, err := Login(context.Background(), Options{BaseUrl: baseUrl}, LoginInput{
+				Username:  mockUsername,
+				Responder: email,
+			})
+			if !errors.Is(err, ErrLoginIncomplete) {
+				t.Errorf("got error %v, want ErrLoginIncomplete", err)
+			}
+		})
+	}
+}
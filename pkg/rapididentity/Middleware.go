@@ -0,0 +1,155 @@
+package rapididentity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// A single call of a Client operation passing
+// through the middleware chain.
+type Call struct {
+	// The operation being called.
+	Operation Operation
+
+	// The typed input of the operation, for example
+	// GetUserByIdInput. Nil for operations without input
+	// and for custom requests.
+	Input any
+
+	// The HTTP request built for the operation.
+	// Middleware may add headers or replace the
+	// request before calling the next handler.
+	Request *http.Request
+
+	// The HTTP response. Set by the innermost handler.
+	// Its body has already been read into ResponseBody
+	// unless Output is nil.
+	Response *http.Response
+
+	// The response body. Set by the innermost handler
+	// when Output is not nil.
+	ResponseBody []byte
+
+	// The value the response body is decoded into.
+	// A *[]byte receives the raw response body. When
+	// nil, as with DoCustomRequest, the response status
+	// is not checked and the response body is left
+	// unread for the caller.
+	Output any
+}
+
+// Executes a call and returns the decoded error.
+type Handler func(ctx context.Context, call *Call) error
+
+// Wraps a Handler to run code before and after
+// the rest of the chain. For example
+//
+//	func(next rapididentity.Handler) rapididentity.Handler {
+//		return func(ctx context.Context, call *rapididentity.Call) error {
+//			call.Request.Header.Set("X-Request-Id", requestId(ctx))
+//			err := next(ctx, call)
+//			log.Printf("%s: %v", call.Operation.Name, err)
+//			return err
+//		}
+//	}
+type Middleware func(next Handler) Handler
+
+// Builds the handler chain with the first
+// middleware as the outermost handler.
+func chain(middleware []Middleware, handler Handler) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// Runs the operation through the middleware chain
+// decoding the response body into output.
+func (c *Client) invoke(ctx context.Context, op Operation, input any, req *http.Request, output any) error {
+	call := &Call{
+		Operation: op,
+		Input:     input,
+		Request:   req,
+		Output:    output,
+	}
+	return c.handler(ctx, call)
+}
+
+// The innermost handler that sends the
+// request and decodes the response.
+func (c *Client) handle(ctx context.Context, call *Call) error {
+	if c.closed.Load() && !closing(call.Operation) {
+		return ErrClientClosed
+	}
+	if c.capabilities != nil {
+		if err := c.capabilities.check(ctx, c, call.Operation); err != nil {
+			return err
+		}
+	}
+	if call.Operation.Mutating && c.isDryRun(ctx) {
+		return c.skipDryRun(ctx, call)
+	}
+	if c.cache != nil {
+		return c.cache.handle(ctx, c, call)
+	}
+	return c.exchange(ctx, call)
+}
+
+// Whether the operation is sent by CloseContext,
+// the only one allowed once the client is closed.
+func closing(op Operation) bool {
+	return op == opDeleteSession || op == opEndProxySession
+}
+
+// Sends the request of the call and
+// decodes the response into its output.
+func (c *Client) exchange(ctx context.Context, call *Call) error {
+	res, err := c.do(call.Operation, call.Request.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	call.Response = res
+	if call.Output == nil {
+		return nil
+	}
+
+	resBody, err := c.ReceiveResponse(res)
+	if err != nil {
+		return err
+	}
+
+	err = decodeOutput(call, resBody)
+	if err != nil {
+		return err
+	}
+	c.detectDrift(ctx, call)
+
+	return nil
+}
+
+// Sets the response body of the call and
+// decodes it into the output.
+func decodeOutput(call *Call, resBody []byte) error {
+	call.ResponseBody = resBody
+
+	if raw, ok := call.Output.(*[]byte); ok {
+		*raw = resBody
+		return nil
+	}
+
+	err := json.Unmarshal(resBody, call.Output)
+	if err != nil {
+		return RapidIdentityError{
+			Method:  call.Request.Method,
+			ReqUrl:  call.Request.URL,
+			Message: string(resBody),
+			Reason:  err.Error(),
+			Code:    call.Response.StatusCode,
+			Err:     fmt.Errorf("%w: %w", ErrDecode, err),
+		}
+	}
+
+	return nil
+}
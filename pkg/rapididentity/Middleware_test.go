@@ -0,0 +1,134 @@
+package rapididentity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func setupMiddleware(t *testing.T, middleware ...Middleware) (*Client, *http.ServeMux) {
+	t.Helper()
+	return setupWithOptions(t, Options{Middleware: middleware})
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) error {
+				calls = append(calls, name+" before")
+				err := next(ctx, call)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+	client, mux := setupMiddleware(t, record("outer"), record("inner"))
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	if _, err := client.GetBootstrapInfo(context.Background()); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	got := strings.Join(calls, ", ")
+	want := "outer before, inner before, inner after, outer after"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMiddlewareCall(t *testing.T) {
+	t.Parallel()
+	var call *Call
+	var callErr error
+	client, mux := setupMiddleware(t, func(next Handler) Handler {
+		return func(ctx context.Context, c *Call) error {
+			c.Request.Header.Set("X-Request-Id", "1234")
+			callErr = next(ctx, c)
+			call = c
+			return callErr
+		}
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/ldap/users/{dnOrId}", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "X-Request-Id", "1234")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "user not found")
+	})
+
+	input := GetUserByIdInput{Id: "1234"}
+	_, err := client.GetUserById(context.Background(), input)
+	if err == nil {
+		t.Fatal("got no error, want one")
+	}
+
+	if got, want := call.Operation.String(), "GET /admin/ldap/users/{dnOrId}"; got != want {
+		t.Errorf("operation: got %s, want %s", got, want)
+	}
+	if got, ok := call.Input.(GetUserByIdInput); !ok || got != input {
+		t.Errorf("input: got %+v, want %+v", call.Input, input)
+	}
+	if got, want := call.Response.StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("status code: got %d, want %d", got, want)
+	}
+	riError, ok := callErr.(RapidIdentityError)
+	if !ok || riError.Code != http.StatusNotFound {
+		t.Errorf("error: got %v, want RapidIdentityError with status %d", callErr, http.StatusNotFound)
+	}
+}
+
+func TestMiddlewareDecodeError(t *testing.T) {
+	t.Parallel()
+	var callErr error
+	client, mux := setupMiddleware(t, func(next Handler) Handler {
+		return func(ctx context.Context, c *Call) error {
+			callErr = next(ctx, c)
+			return callErr
+		}
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/connect/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `not json`)
+	})
+
+	_, err := client.GetConnectJobs(context.Background(), GetConnectJobsInput{})
+	if err == nil {
+		t.Fatal("got no error, want one")
+	}
+	if callErr != err {
+		t.Errorf("middleware error: got %v, want %v", callErr, err)
+	}
+}
+
+func TestMiddlewareCustomRequest(t *testing.T) {
+	t.Parallel()
+	var operation Operation
+	client, mux := setupMiddleware(t, func(next Handler) Handler {
+		return func(ctx context.Context, c *Call) error {
+			operation = c.Operation
+			return next(ctx, c)
+		}
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/workflow/resources", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "[]")
+	})
+
+	res, err := client.DoCustomRequest(context.Background(), "GET", "admin/workflow/resources?limit=5", nil)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	res.Body.Close()
+
+	if got, want := operation.String(), "GET /admin/workflow/resources"; got != want {
+		t.Errorf("operation: got %s, want %s", got, want)
+	}
+	if got, want := operation.Name, "DoCustomRequest"; got != want {
+		t.Errorf("operation name: got %s, want %s", got, want)
+	}
+}
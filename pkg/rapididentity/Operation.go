@@ -0,0 +1,104 @@
+package rapididentity
+
+import (
+	"net/http"
+	"strings"
+)
+
+// A RapidIdentity REST API operation wrapped
+// by a Client method.
+type Operation struct {
+	// The Client method name.
+	// For example GetConnectActions.
+	Name string
+
+	// The HTTP method of the operation as
+	// shown in the //meta:operation annotation.
+	Method string
+
+	// The path of the operation relative to /api/rest
+	// as shown in the //meta:operation annotation.
+	// For example /admin/connect/actions/{nameOrId}.
+	Path string
+
+	// Whether sending the operation more than once
+	// has the same effect as sending it once.
+	Idempotent bool
+
+	// Whether the operation changes the tenant.
+	// Mutating operations are not sent in dry-run mode.
+	Mutating bool
+
+	// The minimum RapidIdentity version of the tenant.
+	// Checked when Options.Capabilities is set.
+	MinVersion string
+
+	// The module, named by its ModuleInfo JSON key, that
+	// must be licensed. Checked when Options.Capabilities
+	// is set.
+	Module string
+}
+
+// Returns the operation in the //meta:operation format.
+// For example "GET /admin/connect/actions".
+func (o Operation) String() string {
+	return o.Method + " " + o.Path
+}
+
+// Operations wrapped by the Client methods. MinVersion is
+// the release that introduced the endpoint, for operations
+// newer than the first RapidIdentity REST API.
+var (
+	opCreateSession                    = Operation{Name: "New", Method: "POST", Path: "/sessions"}
+	opDeleteSession                    = Operation{Name: "Close", Method: "DELETE", Path: "/sessions", Idempotent: true}
+	opGetCurrentSession                = Operation{Name: "GetCurrentSession", Method: "GET", Path: "/sessions", Idempotent: true}
+	opListSessionsForUser              = Operation{Name: "ListSessionsForUser", Method: "GET", Path: "/admin/sessions/for/{userId}", Idempotent: true, MinVersion: "2021.1"}
+	opRevokeSession                    = Operation{Name: "RevokeSession", Method: "DELETE", Path: "/admin/sessions/{sessionId}", Idempotent: true, Mutating: true, MinVersion: "2021.1"}
+	opRevokeSessionsForUser            = Operation{Name: "RevokeSessionsForUser", Method: "DELETE", Path: "/admin/sessions/for/{userId}", Idempotent: true, Mutating: true, MinVersion: "2021.1"}
+	opStartLogin                       = Operation{Name: "Login", Method: "POST", Path: "/authn/v1/username", MinVersion: "2020.1"}
+	opLoginStep                        = Operation{Name: "Login", Method: "POST", Path: "/authn/v1/{method}", MinVersion: "2020.1"}
+	opStartProxySession                = Operation{Name: "ProxyAs", Method: "POST", Path: "/sessions/proxy", MinVersion: "2021.1"}
+	opEndProxySession                  = Operation{Name: "Close", Method: "DELETE", Path: "/sessions/proxy", Idempotent: true}
+	opGetAuthenticationPoliciesForUser = Operation{Name: "GetAuthenticationPoliciesForUser", Method: "POST", Path: "/authn/v1/username", Idempotent: true, MinVersion: "2020.1"}
+	opGetBootstrapInfo                 = Operation{Name: "GetBootstrapInfo", Method: "GET", Path: "/bootstrapInfo", Idempotent: true}
+	opGetRapidIdentityAttributes       = Operation{Name: "GetRapidIdentityAttributes", Method: "GET", Path: "/admin/ldap/schema/attributes", Idempotent: true}
+	opGetConnectActions                = Operation{Name: "GetConnectActions", Method: "GET", Path: "/admin/connect/actions", Idempotent: true}
+	opGetConnectActionById             = Operation{Name: "GetConnectActionById", Method: "GET", Path: "/admin/connect/actions/{nameOrId}", Idempotent: true}
+	opGetConnectFileContent            = Operation{Name: "GetConnectFileContent", Method: "GET", Path: "/admin/connect/fileContent/{path}", Idempotent: true, MinVersion: "2022.1"}
+	opGetConnectFileContentZip         = Operation{Name: "GetConnectFileContentZip", Method: "GET", Path: "/admin/connect/fileContentZip", Idempotent: true, MinVersion: "2022.1"}
+	opStreamConnectFileContent         = Operation{Name: "StreamConnectFileContent", Method: "GET", Path: "/admin/connect/fileContent/{path}", Idempotent: true, MinVersion: "2022.1"}
+	opStreamConnectFileContentZip      = Operation{Name: "StreamConnectFileContentZip", Method: "GET", Path: "/admin/connect/fileContentZip", Idempotent: true, MinVersion: "2022.1"}
+	opGetConnectFiles                  = Operation{Name: "GetConnectFiles", Method: "GET", Path: "/admin/connect/files/{path}", Idempotent: true, MinVersion: "2022.1"}
+	opGetConnectJobs                   = Operation{Name: "GetConnectJobs", Method: "GET", Path: "/admin/connect/jobs", Idempotent: true, MinVersion: "2021.2"}
+	opGetConnectProjects               = Operation{Name: "GetConnectProjects", Method: "GET", Path: "/admin/connect/projects", Idempotent: true}
+	opSearchConnectActionSets          = Operation{Name: "SearchConnectActionSets", Method: "GET", Path: "/admin/connect/search/actions", Idempotent: true, MinVersion: "2022.1"}
+	opSaveConnectAction                = Operation{Name: "SaveConnectAction", Method: "POST", Path: "/admin/connect/actions", Mutating: true}
+	opRunConnectAction                 = Operation{Name: "RunConnectAction", Method: "POST", Path: "/admin/connect/run", Mutating: true}
+	opDeleteConnectActionById          = Operation{Name: "DeleteConnectActionById", Method: "DELETE", Path: "/admin/connect/actions/{nameOrId}", Idempotent: true, Mutating: true}
+	opGetDelegationsForUser            = Operation{Name: "GetDelegationsForUser", Method: "GET", Path: "/profiles/aggregated/for/{userId}", Idempotent: true, Module: "profiles"}
+	opGetUserById                      = Operation{Name: "GetUserById", Method: "GET", Path: "/admin/ldap/users/{dnOrId}", Idempotent: true}
+	opRunUserQuery                     = Operation{Name: "RunUserQuery", Method: "POST", Path: "/users", Idempotent: true}
+	opSetPassword                      = Operation{Name: "SetPassword", Method: "POST", Path: "/profiles/actions/password", Mutating: true, Module: "profiles"}
+	opGetPasswordPoliciesFor           = Operation{Name: "GetPasswordPoliciesFor", Method: "POST", Path: "/profiles/passwordPolicies/for", Idempotent: true, Module: "profiles"}
+	opRunAuditReport                   = Operation{Name: "RunAuditReport", Method: "POST", Path: "/reporting/auditQuery", Idempotent: true, Module: "reporting"}
+)
+
+// Builds the operation for a custom request made
+// with DoCustomRequest or DoCustomRequestWithHeaders.
+// Only the idempotent HTTP methods are treated as
+// idempotent, and all but the safe HTTP methods as
+// mutating.
+func customOperation(name string, method string, path string) Operation {
+	path, _, _ = strings.Cut(path, "?")
+	op := Operation{Name: name, Method: method, Path: "/" + path}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		op.Idempotent = true
+	case http.MethodPut, http.MethodDelete:
+		op.Idempotent = true
+		op.Mutating = true
+	default:
+		op.Mutating = true
+	}
+	return op
+}
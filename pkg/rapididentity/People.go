@@ -5,7 +5,8 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
-	"fmt"
+	"net/url"
+	"strconv"
 )
 
 // Input for getting user delegations.
@@ -469,32 +470,17 @@ type CharSet struct {
 func (c *Client) GetDelegationsForUser(ctx context.Context, params GetDelegationsForUserInput) (*GetDelegationsForUserOutput, error) {
 	var output GetDelegationsForUserOutput
 
-	url := fmt.Sprintf("%s/profiles/aggregated/for/%s", c.baseEndpoint, params.UserId)
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
+	endpointUrl := c.endpoint("/profiles/aggregated/for/"+url.PathEscape(params.UserId), nil)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	err = c.invoke(ctx, opGetDelegationsForUser, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(resBody, &output)
-	if err != nil {
-		return nil, RapidIdentityError{
-			Method:  req.Method,
-			ReqUrl:  req.URL,
-			Message: string(resBody),
-			Reason:  err.Error(),
-			Code:    res.StatusCode,
-		}
-	}
-
 	return &output, nil
 }
 
@@ -504,22 +490,13 @@ func (c *Client) GetDelegationsForUser(ctx context.Context, params GetDelegation
 func (c *Client) GetUserById(ctx context.Context, params GetUserByIdInput) (*User, error) {
 	var output User
 
-	url := fmt.Sprintf("%s/admin/ldap/users/%s", c.baseEndpoint, params.Id)
-	req, err := c.GenerateRequest(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	endpointUrl := c.endpoint("/admin/ldap/users/"+url.PathEscape(params.Id), nil)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opGetUserById, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -535,39 +512,27 @@ func (c *Client) RunUserQuery(ctx context.Context, params RunUserQueryInput) (Us
 	limit := cmp.Or(params.Limit, 1000)
 	searchType := cmp.Or(params.SearchType, "advanced")
 
-	url := fmt.Sprintf("%s/users?search=%s&limit=%d", c.baseEndpoint, searchType, limit)
-	query, err := json.Marshal(params.Query)
-	if err != nil {
-		return nil, err
-	}
+	query := url.Values{}
+	query.Set("search", searchType)
+	query.Set("limit", strconv.Itoa(limit))
 	for _, field := range params.DelegationIds {
-		url = fmt.Sprintf("%s&did=%s", url, field)
+		query.Add("did", field)
 	}
-	requestBody := bytes.NewBuffer(query)
-	req, err := c.GenerateRequest(ctx, "POST", url, requestBody)
+	endpointUrl := c.endpoint("/users", query)
+	userQuery, err := json.Marshal(params.Query)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Content-Type", "application/json")
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	requestBody := bytes.NewBuffer(userQuery)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Add("Content-Type", "application/json")
 
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opRunUserQuery, params, req, &output)
 	if err != nil {
-		return nil, RapidIdentityError{
-			Method:  req.Method,
-			ReqUrl:  req.URL,
-			Message: string(resBody),
-			Reason:  err.Error(),
-			Code:    res.StatusCode,
-		}
+		return nil, err
 	}
 
 	return output, nil
@@ -579,38 +544,23 @@ func (c *Client) RunUserQuery(ctx context.Context, params RunUserQueryInput) (Us
 func (c *Client) SetPassword(ctx context.Context, params SetPasswordInput) (SetPasswordOutput, error) {
 	var output SetPasswordOutput
 
-	url := fmt.Sprintf("%s/profiles/actions/password", c.baseEndpoint)
+	endpointUrl := c.endpoint("/profiles/actions/password", nil)
 	body, err := json.Marshal(params)
 	if err != nil {
 		return nil, err
 	}
 	requestBody := bytes.NewBuffer(body)
-	req, err := c.GenerateRequest(ctx, "POST", url, requestBody)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	err = c.invoke(ctx, opSetPassword, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(resBody, &output)
-	if err != nil {
-		return nil, RapidIdentityError{
-			Method:  req.Method,
-			ReqUrl:  req.URL,
-			Message: string(resBody),
-			Reason:  err.Error(),
-			Code:    res.StatusCode,
-		}
-	}
-
 	return output, nil
 }
 
@@ -621,37 +571,22 @@ func (c *Client) GetPasswordPoliciesFor(ctx context.Context, params GetPasswordP
 	var output PasswordPolicy
 	params.Type = cmp.Or(params.Type, "passwordPolicy")
 
-	url := fmt.Sprintf("%s/profiles/passwordPolicies/for", c.baseEndpoint)
+	endpointUrl := c.endpoint("/profiles/passwordPolicies/for", nil)
 	body, err := json.Marshal(params)
 	if err != nil {
 		return nil, err
 	}
 	requestBody := bytes.NewBuffer(body)
-	req, err := c.GenerateRequest(ctx, "POST", url, requestBody)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
+	err = c.invoke(ctx, opGetPasswordPoliciesFor, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(resBody, &output)
-	if err != nil {
-		return nil, RapidIdentityError{
-			Method:  req.Method,
-			ReqUrl:  req.URL,
-			Message: string(resBody),
-			Reason:  err.Error(),
-			Code:    res.StatusCode,
-		}
-	}
-
 	return &output, nil
 }
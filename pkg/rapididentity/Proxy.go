@@ -0,0 +1,91 @@
+package rapididentity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// Params for ProxyAs method.
+type ProxyAsInput struct {
+	// The id of the user to proxy as.
+	UserId string `json:"userId"`
+}
+
+// Starts a proxy session as the target user and returns
+// a derived Client that makes requests as that user. The
+// derived Client shares the http client, retry policy,
+// rate limits, middleware and logger of c. Close the
+// derived Client to end the proxy session; c remains
+// usable with its own session.
+//
+// The proxy session is not renewed when it expires.
+// The tenant must allow ProxyAs for the user of c, see
+// GetBootstrapInfoOutput.AllowProxy.
+//
+//	proxy, err := client.ProxyAs(ctx, rapididentity.ProxyAsInput{UserId: userId})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer proxy.Close()
+//	output, err := proxy.GetDelegationsForUser(ctx, input)
+//
+//meta:operation POST /sessions/proxy
+func (c *Client) ProxyAs(ctx context.Context, params ProxyAsInput) (*Client, error) {
+	endpointUrl := c.endpoint("/sessions/proxy", nil)
+	proxyAs, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	requestBody := bytes.NewBuffer(proxyAs)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	var output Session
+	err = c.invoke(ctx, opStartProxySession, params, req, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := c.derive()
+	proxy.session = &output
+	proxy.proxy = true
+
+	return proxy, nil
+}
+
+// Whether the Client makes requests
+// with a proxy session.
+func (c *Client) IsProxy() bool {
+	return c.proxy
+}
+
+// Returns a Client with the configuration of c and
+// no credentials. The rate limiter is shared so that
+// both clients count against the same tenant limits,
+// and so is the circuit breaker.
+// The response cache is shared as well; its keys
+// include the authorization of each request.
+func (c *Client) derive() *Client {
+	derived := &Client{
+		httpClient:            c.httpClient,
+		userAgent:             c.userAgent,
+		baseEndpoint:          c.baseEndpoint,
+		retryPolicy:           c.retryPolicy,
+		rateLimiter:           c.rateLimiter,
+		circuitBreaker:        c.circuitBreaker,
+		strictDecoding:        c.strictDecoding,
+		cache:                 c.cache,
+		capabilities:          c.capabilities,
+		disableSessionRenewal: c.disableSessionRenewal,
+		dryRun:                c.dryRun,
+		onSessionRenewed:      c.onSessionRenewed,
+		middleware:            c.middleware,
+		logger:                c.logger,
+	}
+	derived.handler = chain(derived.middleware, derived.handle)
+	return derived
+}
@@ -0,0 +1,140 @@
+package rapididentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestProxyAs(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	client, mux, ss := setupSession(t, Options{
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) error {
+				calls.Add(1)
+				return next(ctx, call)
+			}
+		}},
+	})
+	defer client.Close()
+
+	var ended atomic.Bool
+	mux.HandleFunc(baseUrlPath+"/sessions/proxy", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			testHeader(t, r, "Authorization", "Bearer proxy-token")
+			ended.Store(true)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		testMethod(t, r, "POST")
+		testHeader(t, r, "Content-Type", "application/json")
+		if !ss.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var input ProxyAsInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("decode request body: %s", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{
+			"session": {
+				"id": "proxy",
+				"token": "proxy-token",
+				"user": {"id": "%s"},
+				"realUser": {"email": "%s"},
+				"proxyData": {"permissions": ["helpdesk"]}
+			}
+		}`, input.UserId, mockUsername)
+	})
+	mux.HandleFunc(baseUrlPath+"/profiles/aggregated/for/{userId}", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer proxy-token")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		if !ss.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	ctx := context.Background()
+	proxy, err := client.ProxyAs(ctx, ProxyAsInput{UserId: "08b5f0ec"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if !proxy.IsProxy() || client.IsProxy() {
+		t.Errorf("IsProxy: got %t for proxy and %t for client, want true and false", proxy.IsProxy(), client.IsProxy())
+	}
+	if got, want := proxy.session.Session.RealUser.Email, mockUsername; got != want {
+		t.Errorf("real user: got %s, want %s", got, want)
+	}
+
+	if _, err := proxy.GetDelegationsForUser(ctx, GetDelegationsForUserInput{UserId: "08b5f0ec"}); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+	if err := proxy.Close(); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+	if !ended.Load() {
+		t.Error("proxy session was not ended")
+	}
+
+	if _, err := client.GetBootstrapInfo(ctx); err != nil {
+		t.Errorf("client after proxy: got error %s, want none", err)
+	}
+	// Ending the proxy session goes through the middleware too.
+	if got, want := calls.Load(), int32(4); got != want {
+		t.Errorf("middleware calls: got %d, want %d", got, want)
+	}
+}
+
+func TestProxyAsNotRenewed(t *testing.T) {
+	t.Parallel()
+	client, mux, ss := setupSession(t, Options{})
+	defer client.Close()
+
+	mux.HandleFunc(baseUrlPath+"/sessions/proxy", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"session": {"id": "proxy", "token": "proxy-token"}}`)
+	})
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	ctx := context.Background()
+	proxy, err := client.ProxyAs(ctx, ProxyAsInput{UserId: "08b5f0ec"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	_, err = proxy.GetBootstrapInfo(ctx)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got error %v, want ErrUnauthorized", err)
+	}
+	if got, want := ss.sessions.Load(), int32(1); got != want {
+		t.Errorf("sessions created: got %d, want %d", got, want)
+	}
+}
+
+func TestProxyAsForbidden(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/sessions/proxy", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "proxy as is not allowed"}`)
+	})
+
+	_, err := client.ProxyAs(context.Background(), ProxyAsInput{UserId: "08b5f0ec"})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("got error %v, want ErrForbidden", err)
+	}
+}
@@ -127,29 +127,65 @@
 //
 //		fmt.Printf("%+v\n", output)
 //	}
+//
+// # Credentials
+//
+// NewFromEnvironment resolves the BaseUrl and the credentials
+// through a CredentialsProvider instead of hard-coding them. By
+// default the RI_URL, RI_KEY, RI_USER and RI_PWD environment
+// variables are read, followed by the profile named by RI_PROFILE
+// in ~/.rapididentity/config. Use ChainProvider, ProfileProvider
+// and CommandProvider to build another chain.
+//
+//	client, err := rapididentity.NewFromEnvironment(rapididentity.Options{
+//		Credentials: rapididentity.ProfileProvider{Profile: "district2"},
+//	})
+//
+// # Errors
+//
+// Unsuccessful responses are returned as a RapidIdentityError. Use
+// errors.Is with the sentinel errors, such as ErrNotFound and
+// ErrUnauthorized, to check for common failures without comparing
+// status codes, and errors.As to retrieve the RapidIdentityError
+// with the structured ErrorPayload returned by the server.
+//
+//	output, err := client.GetUserById(ctx, input)
+//	if errors.Is(err, rapididentity.ErrNotFound) {
+//		log.Fatalf("user %s does not exist", input.Id)
+//	}
+//	var riError rapididentity.RapidIdentityError
+//	if errors.As(err, &riError) {
+//		log.Fatalf("Status Code: %d, Reason: %s", riError.Code, riError.Reason)
+//	}
 package rapididentity
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	Version          = "v1.7.0"
 	MainProject      = "<Main>" // Represents the <Main> project. Some methods require this value vs an empty string for identifying the <Main> project
 	defaultUserAgent = "ri-sdk-go" + "/" + Version
+
+	// How long Close waits for the session to be revoked.
+	defaultCloseTimeout = 30 * time.Second
 )
 
 // Configurable options for the RapidIdentity
 // Client.
 type Options struct {
 	// The http client to use to make requests
-	// to the RapidIdentity REST API
+	// to the RapidIdentity REST API.
+	// The default is http.DefaultClient
 	HTTPClient *http.Client
 
 	// The service identity key to use for authorization.
@@ -167,9 +203,72 @@ type Options struct {
 	// Do NOT add a trailing slash
 	BaseUrl *url.URL
 
+	// Resolves the BaseUrl and the credentials when
+	// they are not set. See NewFromEnvironment.
+	Credentials CredentialsProvider
+
 	// The user agent to used in requests.
 	// The default is the ri-sdk-go user agent
 	UserAgent string
+
+	// The retry policy for failed requests.
+	// If nil, requests are not retried.
+	RetryPolicy *RetryPolicy
+
+	// The client-side rate limits for requests.
+	// If nil, requests are not rate limited.
+	RateLimit *RateLimitOptions
+
+	// Disables the automatic renewal of the user session.
+	// By default, when RapidIdentityUser is set and the
+	// session expires or is invalidated, the client
+	// re-authenticates with the RapidIdentityUser
+	// credentials and replays the request once.
+	DisableSessionRenewal bool
+
+	// Called after the user session has been renewed
+	// with the previous and the new session.
+	OnSessionRenewed func(previous *Session, renewed *Session)
+
+	// Middleware wrapping every operation, including
+	// DoCustomRequest and DoCustomRequestWithHeaders.
+	// The first middleware is the outermost.
+	Middleware []Middleware
+
+	// The logger for requests made by the client.
+	// Requests are logged at info level, failed requests
+	// at warn or error level, and redacted headers and
+	// bodies at debug level. If nil, nothing is logged.
+	Logger *slog.Logger
+
+	// Caches the responses of slow-changing read
+	// operations. If nil, responses are not cached.
+	Cache *CacheOptions
+
+	// Fails requests fast while a tenant keeps failing.
+	// Share one CircuitBreaker between clients to report
+	// the circuits of all of them. If nil, requests are
+	// always sent.
+	CircuitBreaker *CircuitBreaker
+
+	// Checks every operation against the version and the
+	// licensed modules of the tenant, and returns an
+	// *UnsupportedError instead of sending it when they
+	// do not meet its requirement. If nil, operations
+	// are always sent.
+	Capabilities *CapabilityOptions
+
+	// Compares every decoded response with its output
+	// type and reports extra and missing JSON fields.
+	// If nil, responses are decoded as usual.
+	StrictDecoding *StrictDecodingOptions
+
+	// Builds the requests of mutating operations, such as
+	// SaveConnectAction or SetPassword, without sending
+	// them and returns a *DryRunError holding the planned
+	// request. Read operations are sent as usual. Override
+	// it per call with WithDryRun.
+	DryRun bool
 }
 
 // RapidIdentity username and password for
@@ -183,12 +282,43 @@ type RapidIdentityUser struct {
 }
 
 // Client to make RapidIdentity REST API Calls.
+//
+// A Client is safe for concurrent use by multiple
+// goroutines and should be shared rather than created
+// per request. Calls made after Close fail with
+// ErrClientClosed.
 type Client struct {
-	httpClient         *http.Client
-	serviceIdentityKey string
-	session            *Session
-	userAgent          string
-	baseEndpoint       string
+	httpClient            *http.Client
+	serviceIdentityKey    string
+	rapidIdentityUser     *RapidIdentityUser
+	session               *Session
+	userAgent             string
+	baseEndpoint          string
+	retryPolicy           *RetryPolicy
+	rateLimiter           *rateLimiter
+	circuitBreaker        *CircuitBreaker
+	cache                 *responseCache
+	capabilities          *capabilities
+	strictDecoding        *StrictDecodingOptions
+	disableSessionRenewal bool
+	dryRun                bool
+	onSessionRenewed      func(previous *Session, renewed *Session)
+	middleware            []Middleware
+	handler               Handler
+	logger                *slog.Logger
+
+	// Whether session is a proxy session
+	// started with ProxyAs.
+	proxy bool
+
+	// Guards session.
+	mu sync.RWMutex
+
+	// Serializes session renewals.
+	renewMu sync.Mutex
+
+	// Set by Close.
+	closed atomic.Bool
 }
 
 // Generates a base RapidIdentity API request that
@@ -199,17 +329,47 @@ func (c *Client) GenerateRequest(ctx context.Context, method string, url string,
 		return nil, err
 	}
 
-	token := c.serviceIdentityKey
-	if c.session != nil {
-		token = c.session.Session.Token
+	if token := c.token(); token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
 	}
-	req.Header.Add("Authorization", "Bearer "+token)
 	req.Header.Add("UserAgent", c.userAgent)
 	req.Header.Add("Accept", "application/json")
 
 	return req, nil
 }
 
+// Sends a single attempt of the request for the
+// operation through the circuit breaker if any.
+func (c *Client) send(op Operation, req *http.Request) (*http.Response, error) {
+	if c.circuitBreaker != nil {
+		return c.sendWithCircuitBreaker(op, req)
+	}
+	return c.attempt(op, req)
+}
+
+// Sends a single attempt of the request for the
+// operation, renewing the user session if needed.
+func (c *Client) attempt(op Operation, req *http.Request) (*http.Response, error) {
+	if c.canRenewSession() {
+		return c.sendWithSession(op, req)
+	}
+	return c.roundTrip(op, req)
+}
+
+// Sends the HTTP request once the rate
+// limiter allows it.
+func (c *Client) roundTrip(op Operation, req *http.Request) (*http.Response, error) {
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.wait(req.Context(), op); err != nil {
+			return nil, err
+		}
+	}
+	if c.logger != nil {
+		return c.logRoundTrip(op, req)
+	}
+	return c.httpClient.Do(req)
+}
+
 // Handles the responses provided by the
 // RapidIdentity REST API
 func (c *Client) ReceiveResponse(res *http.Response) ([]byte, error) {
@@ -222,64 +382,69 @@ func (c *Client) ReceiveResponse(res *http.Response) ([]byte, error) {
 			Message: string(resBody),
 			Reason:  err.Error(),
 			Code:    res.StatusCode,
+			Err:     err,
 		}
 	}
 
 	if res.StatusCode >= 200 && res.StatusCode < 300 {
 		return resBody, nil
 	} else {
-		return nil, RapidIdentityError{
-			Method:  res.Request.Method,
-			ReqUrl:  res.Request.URL,
-			Message: string(resBody),
-			Reason:  string(resBody),
-			Code:    res.StatusCode,
-		}
+		return nil, newStatusError(res.Request, res.StatusCode, resBody, string(resBody))
 	}
 }
 
 // If user session is available the session
-// is revoked.
+// is revoked. For a Client returned by ProxyAs
+// the proxy session is ended instead.
+//
+// Close may be called more than once and concurrently
+// with other calls. Calls already in flight complete,
+// while new calls fail with ErrClientClosed. Only the
+// first call revokes the session.
+//
+// Close calls CloseContext with a context that
+// times out after 30 seconds.
 func (c *Client) Close() error {
-	if c.session != nil {
-		url := fmt.Sprintf("%s/sessions", c.baseEndpoint)
-		req, err := http.NewRequest("DELETE", url, nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Add("Authorization", "Bearer "+c.session.Session.Token)
-		req.Header.Add("User-Agent", c.userAgent)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+	return c.CloseContext(ctx)
+}
 
-		res, err := c.httpClient.Do(req)
-		if err != nil {
-			return err
-		}
+// Closes the Client like Close, revoking the session
+// with the context. The DELETE request is sent through
+// the middleware and the retry, rate limit and circuit
+// breaker options like any other operation, as
+// opDeleteSession or opEndProxySession.
+func (c *Client) CloseContext(ctx context.Context) error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return nil
+	}
 
-		if res.StatusCode >= 200 && res.StatusCode < 300 {
-			c.session = nil
-			return nil
-		}
+	// Wait for an in-flight session renewal
+	// so that its session is revoked as well.
+	c.renewMu.Lock()
+	c.mu.Lock()
+	session := c.session
+	c.session = nil
+	c.mu.Unlock()
+	c.renewMu.Unlock()
+
+	if session == nil {
+		return nil
+	}
 
-		resBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			return RapidIdentityError{
-				Method:  req.Method,
-				ReqUrl:  req.URL,
-				Message: string(resBody),
-				Reason:  err.Error(),
-				Code:    res.StatusCode,
-			}
-		}
-		return RapidIdentityError{
-			Method:  req.Method,
-			ReqUrl:  req.URL,
-			Message: string(resBody),
-			Reason:  "Unknown",
-			Code:    res.StatusCode,
-		}
+	op, path := opDeleteSession, "/sessions"
+	if c.proxy {
+		op, path = opEndProxySession, "/sessions/proxy"
 	}
+	req, err := c.GenerateRequest(ctx, "DELETE", c.endpoint(path, nil), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+session.Session.Token)
 
-	return nil
+	var resBody []byte
+	return c.invoke(ctx, op, nil, req, &resBody)
 }
 
 // DoCustomRequest allows users to make custom API calls
@@ -291,6 +456,10 @@ func (c *Client) Close() error {
 // When the body is present the Content-Type header is
 // set to application/json. If you would like to add
 // additional headers use DoCustomRequestWithHeaders.
+//
+// Requests are retried according to Options.RetryPolicy.
+// Requests with a body are only retried when the body is a
+// *bytes.Buffer, *bytes.Reader or *strings.Reader.
 func (c *Client) DoCustomRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
 	endpointUrl := fmt.Sprintf("%s/%s", c.baseEndpoint, path)
 	req, err := c.GenerateRequest(ctx, method, endpointUrl, body)
@@ -302,12 +471,16 @@ func (c *Client) DoCustomRequest(ctx context.Context, method string, path string
 		req.Header.Add("Content-Type", "application/json")
 	}
 
-	res, err := c.httpClient.Do(req)
+	call := &Call{
+		Operation: customOperation("DoCustomRequest", method, path),
+		Request:   req,
+	}
+	err = c.handler(ctx, call)
 	if err != nil {
 		return nil, err
 	}
 
-	return res, nil
+	return call.Response, nil
 }
 
 // DoCustomRequestWithHeaders allows users to make custom API calls
@@ -337,12 +510,16 @@ func (c *Client) DoCustomRequestWithHeaders(ctx context.Context, method string,
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	res, err := c.httpClient.Do(req)
+	call := &Call{
+		Operation: customOperation("DoCustomRequestWithHeaders", method, path),
+		Request:   req,
+	}
+	err = c.handler(ctx, call)
 	if err != nil {
 		return nil, err
 	}
 
-	return res, nil
+	return call.Response, nil
 }
 
 // Creates a new RapidIdentity Client
@@ -351,81 +528,53 @@ func New(options Options) (*Client, error) {
 	if options.UserAgent == "" {
 		options.UserAgent = defaultUserAgent
 	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	if options.Credentials != nil {
+		var err error
+		options, err = resolveCredentials(context.Background(), options)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	c := &Client{
-		serviceIdentityKey: options.ServiceIdentity,
-		httpClient:         options.HTTPClient,
-		userAgent:          options.UserAgent,
-		baseEndpoint:       fmt.Sprintf("%s/api/rest", options.BaseUrl),
+		serviceIdentityKey:    options.ServiceIdentity,
+		rapidIdentityUser:     options.RapidIdentityUser,
+		httpClient:            options.HTTPClient,
+		userAgent:             options.UserAgent,
+		baseEndpoint:          fmt.Sprintf("%s/api/rest", options.BaseUrl),
+		disableSessionRenewal: options.DisableSessionRenewal,
+		dryRun:                options.DryRun,
+		circuitBreaker:        options.CircuitBreaker,
+		strictDecoding:        options.StrictDecoding,
+		onSessionRenewed:      options.OnSessionRenewed,
+		logger:                options.Logger,
+		middleware:            options.Middleware,
+	}
+
+	c.handler = chain(c.middleware, c.handle)
+	if options.RetryPolicy != nil {
+		c.retryPolicy = options.RetryPolicy.withDefaults()
+	}
+	if options.RateLimit != nil {
+		c.rateLimiter = newRateLimiter(*options.RateLimit)
+	}
+	if options.Cache != nil {
+		c.cache = newResponseCache(*options.Cache, options.Logger)
+	}
+	if options.Capabilities != nil {
+		c.capabilities = newCapabilities(*options.Capabilities)
 	}
 
 	if options.RapidIdentityUser != nil {
-		url := fmt.Sprintf("%s/sessions", c.baseEndpoint)
-		rapidIdentityUser, err := json.Marshal(options.RapidIdentityUser)
-		if err != nil {
-			return nil, err
-		}
-		reqBody := bytes.NewBuffer(rapidIdentityUser)
-		req, err := http.NewRequest("POST", url, reqBody)
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Add("Content-Type", "application/json")
-		req.Header.Add("User-Agent", c.userAgent)
-		res, err := options.HTTPClient.Do(req)
+		session, err := c.createSession(context.Background(), options.RapidIdentityUser)
 		if err != nil {
 			return nil, err
 		}
-		defer res.Body.Close()
-		resBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			return nil, RapidIdentityError{
-				Method:  req.Method,
-				ReqUrl:  req.URL,
-				Message: string(resBody),
-				Reason:  err.Error(),
-				Code:    res.StatusCode,
-			}
-		}
-
-		if res.StatusCode >= 200 && res.StatusCode < 300 {
-			var session Session
-			err = json.Unmarshal(resBody, &session)
-			if err != nil {
-				return nil, RapidIdentityError{
-					Method:  req.Method,
-					ReqUrl:  req.URL,
-					Message: string(resBody),
-					Reason:  err.Error(),
-					Code:    res.StatusCode,
-				}
-			}
-			c.session = &session
-		} else {
-			return nil, RapidIdentityError{
-				Method:  req.Method,
-				ReqUrl:  req.URL,
-				Message: string(resBody),
-				Reason:  "Unknown",
-				Code:    res.StatusCode,
-			}
-		}
-
+		c.session = session
 	}
 
 	return c, nil
 }
-
-// Error message to be used for additional
-// information for all endpoints
-type RapidIdentityError struct {
-	Method  string
-	ReqUrl  *url.URL
-	Message string
-	Reason  string
-	Code    int
-}
-
-func (re RapidIdentityError) Error() string {
-	return re.Message
-}
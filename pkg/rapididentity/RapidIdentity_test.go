@@ -20,19 +20,46 @@ const (
 )
 
 func setup(t *testing.T) (*Client, *http.ServeMux) {
+	t.Helper()
+	return setupWithOptions(t, Options{})
+}
+
+// Creates a test client with the options against a test
+// server. The client authenticates with mockServiceIdentity
+// unless the options set a ServiceIdentity or user.
+func setupWithOptions(t *testing.T, options Options) (*Client, *http.ServeMux) {
 	t.Helper()
 	mux := http.NewServeMux()
-	server := httptest.NewServer(mux)
-	baseUrl, _ := url.Parse(server.URL)
-	client, _ := New(Options{
-		HTTPClient:      &http.Client{},
-		ServiceIdentity: mockServiceIdentity,
-		BaseUrl:         baseUrl,
-	})
+	return newTestClient(t, mux, options), mux
+}
 
-	t.Cleanup(server.Close)
+// Creates a test client with the options against a test server
+// of the handler, for handlers that must be registered before
+// New is called, such as the /sessions of a RapidIdentityUser.
+func newTestClient(t *testing.T, handler http.Handler, options Options) *Client {
+	t.Helper()
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{}
+	}
+	if options.ServiceIdentity == "" && options.RapidIdentityUser == nil {
+		options.ServiceIdentity = mockServiceIdentity
+	}
+	options.BaseUrl = newTestServer(t, handler)
+	client, err := New(options)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	return client
+}
 
-	return client, mux
+// Starts a test server of the handler, closed
+// when the test ends, and returns its URL.
+func newTestServer(t *testing.T, handler http.Handler) *url.URL {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	baseUrl, _ := url.Parse(server.URL)
+	return baseUrl
 }
 
 func testMethod(t *testing.T, r *http.Request, want string) {
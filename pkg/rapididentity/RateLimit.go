@@ -0,0 +1,137 @@
+package rapididentity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// A token bucket rate limit. Tokens are added
+// at Rate per second up to Burst tokens, and every
+// request sent takes one token.
+type RateLimit struct {
+	// The average number of requests per second.
+	// Zero or less means unlimited.
+	Rate float64
+
+	// The maximum number of requests that can be
+	// sent at once. The default is the Rate rounded
+	// up, with a minimum of 1.
+	Burst int
+}
+
+// Client-side rate limits shared by every goroutine
+// using the Client. When no tokens are available
+// requests block until a token is available or the
+// context is done. If the context deadline would pass
+// before a token is available the request fails
+// immediately with an error wrapping
+// context.DeadlineExceeded.
+type RateLimitOptions struct {
+	// The rate limit applied to every operation
+	// without an override in Operations.
+	Default RateLimit
+
+	// Per-operation rate limits keyed by the
+	// //meta:operation name, for example
+	// "GET /admin/ldap/users/{dnOrId}". An override
+	// has its own bucket and does not take tokens
+	// from the Default bucket.
+	Operations map[string]RateLimit
+}
+
+// Rate limiter for the Client holding a token
+// bucket for the default limit and one per
+// operation override.
+type rateLimiter struct {
+	defaultBucket *tokenBucket
+	operations    map[string]*tokenBucket
+}
+
+func newRateLimiter(options RateLimitOptions) *rateLimiter {
+	rl := &rateLimiter{
+		defaultBucket: newTokenBucket(options.Default),
+		operations:    make(map[string]*tokenBucket, len(options.Operations)),
+	}
+	for name, limit := range options.Operations {
+		rl.operations[name] = newTokenBucket(limit)
+	}
+	return rl
+}
+
+// Blocks until the operation may be sent.
+func (rl *rateLimiter) wait(ctx context.Context, op Operation) error {
+	if bucket, ok := rl.operations[op.String()]; ok {
+		return bucket.wait(ctx)
+	}
+	return rl.defaultBucket.wait(ctx)
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// Creates a token bucket for the limit. A nil bucket
+// is returned for an unlimited rate.
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if limit.Burst <= 0 {
+		burst = max(math.Ceil(limit.Rate), 1)
+	}
+	return &tokenBucket{
+		rate:   limit.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// Takes a token from the bucket, blocking until one
+// is available. The token is returned to the bucket if
+// the context is done before it becomes available.
+func (tb *tokenBucket) wait(ctx context.Context) error {
+	if tb == nil {
+		return nil
+	}
+
+	tb.mu.Lock()
+	now := time.Now()
+	tb.tokens = min(tb.burst, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+	tb.last = now
+	tb.tokens--
+	var delay time.Duration
+	if tb.tokens < 0 {
+		delay = time.Duration(-tb.tokens / tb.rate * float64(time.Second))
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		tb.tokens++
+		tb.mu.Unlock()
+		return fmt.Errorf("rate limit wait of %s exceeds the context deadline: %w", delay, context.DeadlineExceeded)
+	}
+	tb.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		tb.mu.Lock()
+		tb.tokens++
+		tb.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupRateLimit(t *testing.T, options RateLimitOptions) (*Client, *http.ServeMux) {
+	t.Helper()
+	return setupWithOptions(t, Options{RateLimit: &options})
+}
+
+func TestRateLimitSharedAcrossGoroutines(t *testing.T) {
+	t.Parallel()
+	client, mux := setupRateLimit(t, RateLimitOptions{
+		Default: RateLimit{Rate: 50, Burst: 1},
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/ldap/users/{dnOrId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"id": "%s"}`, r.PathValue("dnOrId"))
+	})
+
+	const requests = 6
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			input := GetUserByIdInput{Id: fmt.Sprint(i)}
+			if _, err := client.GetUserById(context.Background(), input); err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The first request uses the burst token and the
+	// remaining requests wait 20ms each.
+	if got, want := time.Since(start), (requests-1)*20*time.Millisecond; got < want-5*time.Millisecond {
+		t.Errorf("elapsed: got %s, want at least %s", got, want)
+	}
+}
+
+func TestRateLimitContextDeadline(t *testing.T) {
+	t.Parallel()
+	client, mux := setupRateLimit(t, RateLimitOptions{
+		Default: RateLimit{Rate: 0.1, Burst: 1},
+	})
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	if _, err := client.GetBootstrapInfo(context.Background()); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.GetBootstrapInfo(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %s", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("elapsed: got %s, want the request to fail without waiting", elapsed)
+	}
+}
+
+func TestRateLimitOperationOverride(t *testing.T) {
+	t.Parallel()
+	client, mux := setupRateLimit(t, RateLimitOptions{
+		Default: RateLimit{Rate: 0.1, Burst: 1},
+		Operations: map[string]RateLimit{
+			"GET /admin/connect/projects": {Rate: 1000, Burst: 10},
+		},
+	})
+	mux.HandleFunc(baseUrlPath+"/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"projects": []}`)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for range 5 {
+		if _, err := client.GetConnectProjects(ctx); err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+	}
+}
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	t.Parallel()
+	if bucket := newTokenBucket(RateLimit{}); bucket != nil {
+		t.Fatalf("got bucket %+v, want nil", bucket)
+	}
+
+	var bucket *tokenBucket
+	if err := bucket.wait(context.Background()); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
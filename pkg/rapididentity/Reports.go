@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"net/url"
+	"strconv"
 )
 
 type AuditReportOperator string
@@ -255,36 +256,27 @@ type AuditReportExtendedProperties struct {
 //
 //meta:operation POST /reporting/auditQuery
 func (c *Client) RunAuditReport(ctx context.Context, params RunAuditReportInput) (*RunAuditReportOutput, error) {
-	url := fmt.Sprintf("%s/reporting/auditQuery", c.baseEndpoint)
-	sep := "?"
+	query := url.Values{}
 	if params.PageSize > 0 {
-		url += fmt.Sprintf("%spage_size=%d", sep, params.PageSize)
-		sep = "&"
+		query.Set("page_size", strconv.Itoa(params.PageSize))
 	}
 	if params.PageToken != "" {
-		url += fmt.Sprintf("%spage_token=%s", sep, params.PageToken)
+		query.Set("page_token", params.PageToken)
 	}
-	query, err := json.Marshal(params.Query)
+	endpointUrl := c.endpoint("/reporting/auditQuery", query)
+	auditQuery, err := json.Marshal(params.Query)
 	if err != nil {
 		return nil, err
 	}
-	requestBody := bytes.NewBuffer(query)
-	req, err := c.GenerateRequest(ctx, "POST", url, requestBody)
+	requestBody := bytes.NewBuffer(auditQuery)
+	req, err := c.GenerateRequest(ctx, "POST", endpointUrl, requestBody)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	resBody, err := c.ReceiveResponse(res)
-	if err != nil {
-		return nil, err
-	}
 	var output RunAuditReportOutput
-	err = json.Unmarshal(resBody, &output)
+	err = c.invoke(ctx, opRunAuditReport, params, req, &output)
 	if err != nil {
 		return nil, err
 	}
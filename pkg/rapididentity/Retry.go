@@ -0,0 +1,189 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 30 * time.Second
+)
+
+// Retry policy applied to every request made
+// by the Client, including DoCustomRequest and
+// DoCustomRequestWithHeaders.
+//
+// Idempotent operations, such as GET requests and
+// read only queries, are retried freely. Operations
+// that are not idempotent, such as SetPassword,
+// SaveConnectAction and RunConnectAction, are only
+// retried when RetryNonIdempotent is true.
+type RetryPolicy struct {
+	// The maximum number of attempts including
+	// the first one. The default is 3.
+	MaxAttempts int
+
+	// The base delay of the exponential backoff.
+	// The delay before attempt n is a random duration
+	// between zero and BaseDelay * 2^(n-2), capped at
+	// MaxDelay. The default is 500ms.
+	BaseDelay time.Duration
+
+	// The maximum delay between attempts. A Retry-After
+	// header asking for a longer delay stops the retries
+	// and the response is returned as is.
+	// The default is 30s.
+	MaxDelay time.Duration
+
+	// The HTTP status codes that are retried.
+	// The default is 429, 502, 503 and 504.
+	RetryableStatusCodes []int
+
+	// Whether to retry operations that are not
+	// idempotent such as SetPassword and RunConnectAction.
+	// The default is false.
+	RetryNonIdempotent bool
+}
+
+// Returns a copy of the retry policy with
+// the defaults applied.
+func (rp RetryPolicy) withDefaults() *RetryPolicy {
+	if rp.MaxAttempts <= 0 {
+		rp.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if rp.BaseDelay <= 0 {
+		rp.BaseDelay = defaultRetryBaseDelay
+	}
+	if rp.MaxDelay <= 0 {
+		rp.MaxDelay = defaultRetryMaxDelay
+	}
+	if rp.RetryableStatusCodes == nil {
+		rp.RetryableStatusCodes = []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+	return &rp
+}
+
+// Returns the delay before the attempt following
+// the provided attempt using exponential backoff
+// with full jitter.
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := rp.MaxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := rp.BaseDelay << shift; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	return rand.N(ceiling + 1)
+}
+
+// Whether the result of an attempt should be retried.
+func (rp *RetryPolicy) retryable(res *http.Response, err error) bool {
+	if err != nil {
+		// Errors carrying a response, such as a 401 whose
+		// session renewal failed, follow its status code.
+		var riError RapidIdentityError
+		if errors.As(err, &riError) && riError.Code != 0 && riError.Err == nil {
+			return slices.Contains(rp.RetryableStatusCodes, riError.Code)
+		}
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrCircuitOpen)
+	}
+	return slices.Contains(rp.RetryableStatusCodes, res.StatusCode)
+}
+
+// Parses the Retry-After header of the response which
+// is either a number of seconds or an HTTP date.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// Sends the request for the operation applying the
+// client retry policy. Requests with a body are only
+// retried when the body can be rewound with GetBody.
+func (c *Client) do(op Operation, req *http.Request) (*http.Response, error) {
+	policy := c.retryPolicy
+	if policy == nil || (!op.Idempotent && !policy.RetryNonIdempotent) {
+		return c.send(op, req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		res, err := c.send(op, req)
+		if attempt >= policy.MaxAttempts || !policy.retryable(res, err) || ctx.Err() != nil {
+			return res, err
+		}
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return res, err
+		}
+
+		delay := policy.backoff(attempt)
+		if res != nil {
+			if after, ok := retryAfter(res); ok {
+				delay = after
+			}
+		}
+		if delay > policy.MaxDelay {
+			return res, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return res, err
+		}
+
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		if c.logger != nil {
+			c.logger.LogAttrs(ctx, slog.LevelDebug, "retrying rapididentity request",
+				slog.String("operation", op.Name),
+				slog.Int("attempt", attempt+1),
+				slog.Duration("delay", delay),
+			)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		next := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			next.Body = body
+		}
+		req = next
+	}
+}
@@ -0,0 +1,231 @@
+package rapididentity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setupRetry(t *testing.T, policy *RetryPolicy) (*Client, *http.ServeMux) {
+	t.Helper()
+	return setupWithOptions(t, Options{RetryPolicy: policy})
+}
+
+func TestRetryIdempotentOperation(t *testing.T) {
+	t.Parallel()
+	client, mux := setupRetry(t, &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+	})
+	var attempts atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"projects": [{"name": "sec_mgr"}]}`)
+	})
+
+	ctx := context.Background()
+	output, err := client.GetConnectProjects(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	if got, want := attempts.Load(), int32(3); got != want {
+		t.Errorf("attempts: got %d, want %d", got, want)
+	}
+	if got, want := len(output.Projects), 1; got != want {
+		t.Errorf("projects: got %d, want %d", got, want)
+	}
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	t.Parallel()
+	client, mux := setupRetry(t, &RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Millisecond,
+	})
+	var attempts atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "bad gateway")
+	})
+
+	ctx := context.Background()
+	_, err := client.GetBootstrapInfo(ctx)
+	riError, ok := err.(RapidIdentityError)
+	if !ok {
+		t.Fatalf("got error %v, want RapidIdentityError", err)
+	}
+
+	if got, want := riError.Code, http.StatusBadGateway; got != want {
+		t.Errorf("status code: got %d, want %d", got, want)
+	}
+	if got, want := attempts.Load(), int32(2); got != want {
+		t.Errorf("attempts: got %d, want %d", got, want)
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	t.Parallel()
+	client, mux := setupRetry(t, &RetryPolicy{
+		BaseDelay: time.Millisecond,
+	})
+	var attempts atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/users", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var query AuditReportQuery
+		reqBody, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(reqBody, &query); err != nil || query.FieldName != "givenName" {
+			t.Errorf("request body: got %s, want fieldName givenName", reqBody)
+		}
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `[{"id": "1234"}]`)
+	})
+
+	input := RunUserQueryInput{
+		Query: AuditReportQuery{FieldName: "givenName", OperatorType: EQUAL},
+	}
+	ctx := context.Background()
+	output, err := client.RunUserQuery(ctx, input)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	if got, want := attempts.Load(), int32(2); got != want {
+		t.Errorf("attempts: got %d, want %d", got, want)
+	}
+	if got, want := output[0].Id, "1234"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestRetryNonIdempotentOperation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy *RetryPolicy
+		want   int32
+	}{
+		{
+			name:   "not retried by default",
+			policy: &RetryPolicy{BaseDelay: time.Millisecond},
+			want:   1,
+		},
+		{
+			name:   "retried when opted in",
+			policy: &RetryPolicy{BaseDelay: time.Millisecond, RetryNonIdempotent: true},
+			want:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client, mux := setupRetry(t, tt.policy)
+			var attempts atomic.Int32
+			mux.HandleFunc(baseUrlPath+"/profiles/actions/password", func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+
+			ctx := context.Background()
+			_, err := client.SetPassword(ctx, SetPasswordInput{NewPassword: mockPassword})
+			if err == nil {
+				t.Fatal("got no error, want one")
+			}
+
+			if got := attempts.Load(); got != tt.want {
+				t.Errorf("attempts: got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{name: "missing", header: "", ok: false},
+		{name: "seconds", header: "2", want: 2 * time.Second, ok: true},
+		{name: "negative", header: "-1", ok: false},
+		{name: "past date", header: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0, ok: true},
+		{name: "invalid", header: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			res := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				res.Header.Set("Retry-After", tt.header)
+			}
+			got, ok := retryAfter(res)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("got %s, %t, want %s, %t", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRetryAfterExceedsMaxDelay(t *testing.T) {
+	t.Parallel()
+	client, mux := setupRetry(t, &RetryPolicy{
+		BaseDelay: time.Millisecond,
+		MaxDelay:  time.Second,
+	})
+	var attempts atomic.Int32
+	mux.HandleFunc(baseUrlPath+"/admin/connect/jobs", func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	ctx := context.Background()
+	_, err := client.GetConnectJobs(ctx, GetConnectJobsInput{})
+	riError, ok := err.(RapidIdentityError)
+	if !ok {
+		t.Fatalf("got error %v, want RapidIdentityError", err)
+	}
+
+	if got, want := riError.Code, http.StatusTooManyRequests; got != want {
+		t.Errorf("status code: got %d, want %d", got, want)
+	}
+	if got, want := attempts.Load(), int32(1); got != want {
+		t.Errorf("attempts: got %d, want %d", got, want)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	t.Parallel()
+	policy := RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+	}.withDefaults()
+
+	for attempt := 1; attempt <= 64; attempt++ {
+		ceiling := min(policy.BaseDelay<<min(attempt-1, 31), policy.MaxDelay)
+		if got := policy.backoff(attempt); got < 0 || got > ceiling {
+			t.Errorf("attempt %d: got %s, want between 0 and %s", attempt, got, ceiling)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package rapididentity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Creates a new user session with
+// the RapidIdentity user credentials.
+func (c *Client) createSession(ctx context.Context, user *RapidIdentityUser) (*Session, error) {
+	url := c.endpoint("/sessions", nil)
+	rapidIdentityUser, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+	reqBody := bytes.NewBuffer(rapidIdentityUser)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("User-Agent", c.userAgent)
+	res, err := c.roundTrip(opCreateSession, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, RapidIdentityError{
+			Method:  req.Method,
+			ReqUrl:  req.URL,
+			Message: string(resBody),
+			Reason:  err.Error(),
+			Code:    res.StatusCode,
+			Err:     err,
+		}
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, newStatusError(req, res.StatusCode, resBody, "Unknown")
+	}
+
+	var session Session
+	err = json.Unmarshal(resBody, &session)
+	if err != nil {
+		return nil, RapidIdentityError{
+			Method:  req.Method,
+			ReqUrl:  req.URL,
+			Message: string(resBody),
+			Reason:  err.Error(),
+			Code:    res.StatusCode,
+			Err:     fmt.Errorf("%w: %w", ErrDecode, err),
+		}
+	}
+
+	return &session, nil
+}
+
+// Returns the bearer token used to authorize requests.
+func (c *Client) token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.session != nil {
+		return c.session.Session.Token
+	}
+	return c.serviceIdentityKey
+}
+
+// Whether the client can renew its user session.
+func (c *Client) canRenewSession() bool {
+	return c.rapidIdentityUser != nil && !c.disableSessionRenewal
+}
+
+// Whether the user session is known to be invalidated.
+func (c *Client) sessionInvalidated() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.session != nil && !c.session.Session.Invalidated.IsZero()
+}
+
+// Re-authenticates with the stored RapidIdentity user
+// credentials when the session token is still the stale
+// token. Concurrent callers holding the same stale token
+// wait on a single renewal and reuse its session.
+func (c *Client) renewSession(ctx context.Context, staleToken string) error {
+	c.renewMu.Lock()
+	defer c.renewMu.Unlock()
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+
+	c.mu.RLock()
+	previous := c.session
+	c.mu.RUnlock()
+	if previous != nil && previous.Session.Token != staleToken && previous.Session.Invalidated.IsZero() {
+		return nil
+	}
+
+	session, err := c.createSession(ctx, c.rapidIdentityUser)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.session = session
+	c.mu.Unlock()
+
+	if c.logger != nil {
+		c.logger.LogAttrs(ctx, slog.LevelInfo, "rapididentity session renewed",
+			slog.String("sessionId", session.Session.Id),
+		)
+	}
+	if c.onSessionRenewed != nil {
+		c.onSessionRenewed(previous, session)
+	}
+
+	return nil
+}
+
+// Sends the request, renewing the user session and replaying
+// the request once when the session has expired or has been
+// invalidated. Requests with a body are only replayed when the
+// body can be rewound with GetBody. When the renewal fails its
+// error is returned joined with the 401 of the request.
+func (c *Client) sendWithSession(op Operation, req *http.Request) (*http.Response, error) {
+	staleToken := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if c.sessionInvalidated() {
+		if err := c.renewSession(req.Context(), staleToken); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token())
+		staleToken = c.token()
+	}
+
+	res, err := c.roundTrip(op, req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return res, err
+	}
+	if c.closed.Load() {
+		return res, nil
+	}
+
+	if err := c.renewSession(req.Context(), staleToken); err != nil {
+		resBody, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, errors.Join(
+			newStatusError(req, res.StatusCode, resBody, string(resBody)),
+			fmt.Errorf("rapididentity: unable to renew the session: %w", err),
+		)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	replay := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		replay.Body = body
+	}
+	replay.Header.Set("Authorization", "Bearer "+c.token())
+
+	return c.roundTrip(op, replay)
+}
+
+type SessionInfoList []SessionInfo
+
+func (sil SessionInfoList) MarshalJSON() ([]byte, error) {
+	if sil == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]SessionInfo(sil))
+}
+
+// Params for ListSessionsForUser method.
+type ListSessionsForUserInput struct {
+	// The idautoID of the user to
+	// list the active sessions.
+	UserId string `json:"userId" jsonschema:"The idautoID of the user to list the active sessions."`
+}
+
+// Output for the ListSessionsForUser method.
+type ListSessionsForUserOutput struct {
+	// The active sessions of the user.
+	Sessions SessionInfoList `json:"sessions" jsonschema:"The active sessions of the user."`
+}
+
+// Params for RevokeSession method.
+type RevokeSessionInput struct {
+	// The id of the session to revoke.
+	SessionId string `json:"sessionId" jsonschema:"The id of the session to revoke."`
+}
+
+// Params for RevokeSessionsForUser method.
+type RevokeSessionsForUserInput struct {
+	// The idautoID of the user to
+	// revoke all sessions.
+	UserId string `json:"userId" jsonschema:"The idautoID of the user to revoke all sessions."`
+}
+
+// Returns a copy of the user session of the client
+// as of its creation or the last GetCurrentSession
+// call. Nil when using a Service Identity.
+func (c *Client) Session() *Session {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.session == nil {
+		return nil
+	}
+	session := *c.session
+	return &session
+}
+
+// Retrieves the current user session, including the
+// refreshed LastUsed time and roles, and updates the
+// session returned by Session.
+//
+//meta:operation GET /sessions
+func (c *Client) GetCurrentSession(ctx context.Context) (*Session, error) {
+	var output Session
+
+	endpointUrl := c.endpoint("/sessions", nil)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.invoke(ctx, opGetCurrentSession, nil, req, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.session != nil && output.Session.Id == c.session.Session.Id {
+		if output.Session.Token == "" {
+			output.Session.Token = c.session.Session.Token
+		}
+		session := output
+		c.session = &session
+	}
+	c.mu.Unlock()
+
+	return &output, nil
+}
+
+// Lists the active sessions of a user.
+//
+//meta:operation GET /admin/sessions/for/{userId}
+func (c *Client) ListSessionsForUser(ctx context.Context, params ListSessionsForUserInput) (*ListSessionsForUserOutput, error) {
+	var output ListSessionsForUserOutput
+
+	endpointUrl := c.endpoint("/admin/sessions/for/"+url.PathEscape(params.UserId), nil)
+	req, err := c.GenerateRequest(ctx, "GET", endpointUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.invoke(ctx, opListSessionsForUser, params, req, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+// Revokes a session by id. Revoking the session of
+// the client marks it as invalidated so that it is
+// renewed on the next request if possible.
+//
+//meta:operation DELETE /admin/sessions/{sessionId}
+func (c *Client) RevokeSession(ctx context.Context, params RevokeSessionInput) error {
+	endpointUrl := c.endpoint("/admin/sessions/"+url.PathEscape(params.SessionId), nil)
+	req, err := c.GenerateRequest(ctx, "DELETE", endpointUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	var resBody []byte
+	err = c.invoke(ctx, opRevokeSession, params, req, &resBody)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.session != nil && c.session.Session.Id == params.SessionId {
+		session := *c.session
+		session.Session.Invalidated = time.Now()
+		c.session = &session
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Revokes all sessions of a user,
+// for example when offboarding.
+//
+//meta:operation DELETE /admin/sessions/for/{userId}
+func (c *Client) RevokeSessionsForUser(ctx context.Context, params RevokeSessionsForUserInput) error {
+	endpointUrl := c.endpoint("/admin/sessions/for/"+url.PathEscape(params.UserId), nil)
+	req, err := c.GenerateRequest(ctx, "DELETE", endpointUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	var resBody []byte
+	err = c.invoke(ctx, opRevokeSessionsForUser, params, req, &resBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Fake session server issuing tokens token-1, token-2, ...
+// Only the latest issued token is accepted.
+type sessionServer struct {
+	sessions atomic.Int32
+
+	// Rejects the credentials when set.
+	locked atomic.Bool
+
+	// The session requests, including rejected ones.
+	attempts atomic.Int32
+}
+
+func (ss *sessionServer) currentToken() string {
+	return fmt.Sprintf("token-%d", ss.sessions.Load())
+}
+
+func (ss *sessionServer) authorized(r *http.Request) bool {
+	return r.Header.Get("Authorization") == "Bearer "+ss.currentToken()
+}
+
+func setupSession(t *testing.T, options Options) (*Client, *http.ServeMux, *sessionServer) {
+	t.Helper()
+	ss := &sessionServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseUrlPath+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		testMethod(t, r, "POST")
+		ss.attempts.Add(1)
+		if ss.locked.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "account locked"}`)
+			return
+		}
+		id := ss.sessions.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"session": {"id": "%d", "token": "token-%d"}}`, id, id)
+	})
+	options.RapidIdentityUser = &RapidIdentityUser{
+		Username: mockUsername,
+		Password: mockPassword,
+	}
+	client := newTestClient(t, mux, options)
+
+	return client, mux, ss
+}
+
+func TestSessionRenewal(t *testing.T) {
+	t.Parallel()
+	var renewals []string
+	client, mux, ss := setupSession(t, Options{
+		OnSessionRenewed: func(previous *Session, renewed *Session) {
+			renewals = append(renewals, previous.Session.Token+" -> "+renewed.Session.Token)
+		},
+	})
+	defer client.Close()
+
+	var expired atomic.Bool
+	expired.Store(true)
+	mux.HandleFunc(baseUrlPath+"/profiles/passwordPolicies/for", func(w http.ResponseWriter, r *http.Request) {
+		if expired.CompareAndSwap(true, false) {
+			ss.sessions.Add(1)
+		}
+		if !ss.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"name": "default"}`)
+	})
+
+	ctx := context.Background()
+	output, err := client.GetPasswordPoliciesFor(ctx, GetPasswordPoliciesForInput{})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	if got, want := output.Name, "default"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := strings.Join(renewals, ","), "token-1 -> token-3"; got != want {
+		t.Errorf("renewals: got %s, want %s", got, want)
+	}
+}
+
+func TestSessionRenewalSingleFlight(t *testing.T) {
+	t.Parallel()
+	var renewals atomic.Int32
+	client, mux, ss := setupSession(t, Options{
+		OnSessionRenewed: func(previous *Session, renewed *Session) {
+			renewals.Add(1)
+		},
+	})
+	defer client.Close()
+
+	mux.HandleFunc(baseUrlPath+"/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		if !ss.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"projects": []}`)
+	})
+
+	// Expire the session issued to the client.
+	ss.sessions.Add(1)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetConnectProjects(context.Background()); err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := renewals.Load(), int32(1); got != want {
+		t.Errorf("renewals: got %d, want %d", got, want)
+	}
+}
+
+func TestSessionRenewalFailure(t *testing.T) {
+	t.Parallel()
+	client, mux, ss := setupSession(t, Options{
+		RetryPolicy: &RetryPolicy{BaseDelay: time.Millisecond},
+	})
+
+	mux.HandleFunc(baseUrlPath+"/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		if !ss.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "session expired"}`)
+			return
+		}
+		fmt.Fprint(w, `{"projects": []}`)
+	})
+
+	ctx := context.Background()
+	if _, err := client.GetConnectProjects(ctx); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	// The credentials stop working while the session expires.
+	ss.locked.Store(true)
+	ss.sessions.Add(1)
+	attempts := ss.attempts.Load()
+
+	_, err := client.GetConnectProjects(ctx)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("got error %v, want ErrUnauthorized", err)
+	}
+	var riError RapidIdentityError
+	if !errors.As(err, &riError) || riError.Reason != "session expired" {
+		t.Errorf("got error %v, want the 401 of the request", err)
+	}
+	if !strings.Contains(err.Error(), "unable to renew the session") || !strings.Contains(err.Error(), "account locked") {
+		t.Errorf("got error %v, want the renewal error", err)
+	}
+	if got, want := ss.attempts.Load()-attempts, int32(1); got != want {
+		t.Errorf("session requests: got %d, want %d", got, want)
+	}
+}
+
+func TestSessionRenewalDisabled(t *testing.T) {
+	t.Parallel()
+	client, mux, _ := setupSession(t, Options{
+		DisableSessionRenewal: true,
+	})
+	defer client.Close()
+
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	_, err := client.GetBootstrapInfo(context.Background())
+	riError, ok := err.(RapidIdentityError)
+	if !ok {
+		t.Fatalf("got error %v, want RapidIdentityError", err)
+	}
+
+	if got, want := riError.Code, http.StatusUnauthorized; got != want {
+		t.Errorf("status code: got %d, want %d", got, want)
+	}
+}
+
+func TestSessionRenewalInvalidated(t *testing.T) {
+	t.Parallel()
+	client, mux, ss := setupSession(t, Options{})
+	defer client.Close()
+
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer "+ss.currentToken())
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	client.mu.Lock()
+	client.session.Session.Invalidated = time.Now()
+	client.mu.Unlock()
+
+	if _, err := client.GetBootstrapInfo(context.Background()); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	if got, want := client.token(), "token-2"; got != want {
+		t.Errorf("token: got %s, want %s", got, want)
+	}
+}
+
+func TestGetCurrentSession(t *testing.T) {
+	t.Parallel()
+	client, mux, ss := setupSession(t, Options{})
+	defer client.Close()
+
+	lastUsed := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	mux.HandleFunc("GET "+baseUrlPath+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if !ss.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"session": {"id": "1", "roles": ["admin"], "lastUsed": "%s"}}`, lastUsed.Format(time.RFC3339))
+	})
+
+	output, err := client.GetCurrentSession(context.Background())
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := strings.Join(output.Session.Roles, ","), "admin"; got != want {
+		t.Errorf("roles: got %s, want %s", got, want)
+	}
+
+	session := client.Session()
+	if !session.Session.LastUsed.Equal(lastUsed) {
+		t.Errorf("cached last used: got %s, want %s", session.Session.LastUsed, lastUsed)
+	}
+	if got, want := session.Session.Token, "token-1"; got != want {
+		t.Errorf("cached token: got %s, want %s", got, want)
+	}
+}
+
+func TestListSessionsForUser(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/sessions/for/{userId}", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got, want := r.PathValue("userId"), "08b5f0ec"; got != want {
+			t.Errorf("request user id: got %s, want %s", got, want)
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"sessions": [{"id": "1"}, {"id": "2"}]}`)
+	})
+
+	output, err := client.ListSessionsForUser(context.Background(), ListSessionsForUserInput{UserId: "08b5f0ec"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := len(output.Sessions), 2; got != want {
+		t.Errorf("sessions: got %d, want %d", got, want)
+	}
+	if client.Session() != nil {
+		t.Error("service identity session: got session, want nil")
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	var revoked []string
+	var mu sync.Mutex
+	mux.HandleFunc("DELETE "+baseUrlPath+"/admin/sessions/{sessionId}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		revoked = append(revoked, "session "+r.PathValue("sessionId"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE "+baseUrlPath+"/admin/sessions/for/{userId}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		revoked = append(revoked, "user "+r.PathValue("userId"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ctx := context.Background()
+	if err := client.RevokeSession(ctx, RevokeSessionInput{SessionId: "1234"}); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+	if err := client.RevokeSessionsForUser(ctx, RevokeSessionsForUserInput{UserId: "08b5f0ec"}); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+	if got, want := strings.Join(revoked, ","), "session 1234,user 08b5f0ec"; got != want {
+		t.Errorf("revoked: got %s, want %s", got, want)
+	}
+}
+
+func TestRevokeOwnSessionRenews(t *testing.T) {
+	t.Parallel()
+	client, mux, ss := setupSession(t, Options{})
+	defer client.Close()
+
+	mux.HandleFunc("DELETE "+baseUrlPath+"/admin/sessions/{sessionId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer token-2")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	})
+
+	ctx := context.Background()
+	if err := client.RevokeSession(ctx, RevokeSessionInput{SessionId: "1"}); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if _, err := client.GetBootstrapInfo(ctx); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+	if got, want := ss.sessions.Load(), int32(2); got != want {
+		t.Errorf("sessions created: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package rapididentity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Reports the progress of a download. Transferred is the
+// position in the file, including the resumed offset, and
+// total is the size of the file or -1 if unknown.
+type ProgressFunc func(transferred int64, total int64)
+
+// A streamed download. Read it like any io.Reader and always
+// Close it. The status has been validated, so reads only fail
+// on transport errors, after which the download can be resumed
+// from Offset plus the bytes read.
+type FileStream struct {
+	// The position in the file of the first byte read.
+	// Equals the requested offset.
+	Offset int64
+
+	// The size of the whole file, or -1 if unknown.
+	TotalSize int64
+
+	// The Content-Type of the response.
+	ContentType string
+
+	body        io.ReadCloser
+	transferred int64
+	progress    ProgressFunc
+}
+
+// Creates a FileStream reading the body, for example to
+// return from a mocked StreamConnectFileContent. The total
+// size is -1 if unknown, and progress, if not nil, is called
+// after every read.
+func NewFileStream(body io.ReadCloser, totalSize int64, progress ProgressFunc) *FileStream {
+	return &FileStream{
+		TotalSize: totalSize,
+		body:      body,
+		progress:  progress,
+	}
+}
+
+func (fs *FileStream) Read(p []byte) (int, error) {
+	n, err := fs.body.Read(p)
+	if n > 0 {
+		fs.transferred += int64(n)
+		if fs.progress != nil {
+			fs.progress(fs.Offset+fs.transferred, fs.TotalSize)
+		}
+	}
+	return n, err
+}
+
+func (fs *FileStream) Close() error {
+	return fs.body.Close()
+}
+
+// Sends the request of a download, requesting the bytes from
+// offset on with a Range header, and returns the validated
+// response body. When the server ignores the Range header the
+// bytes before offset are discarded.
+func (c *Client) stream(ctx context.Context, op Operation, input any, req *http.Request, offset int64, progress ProgressFunc) (*FileStream, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("rapididentity: negative offset %d", offset)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	call := &Call{
+		Operation: op,
+		Input:     input,
+		Request:   req,
+	}
+	err := c.handler(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	res := call.Response
+	if res == nil {
+		return nil, fmt.Errorf("rapididentity: %s: the middleware returned no response", op)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		_, err := c.ReceiveResponse(res)
+		return nil, err
+	}
+
+	fs := NewFileStream(res.Body, -1, progress)
+	fs.Offset = offset
+	fs.ContentType = res.Header.Get("Content-Type")
+	if res.StatusCode == http.StatusPartialContent {
+		start, total, ok := parseContentRange(res.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			res.Body.Close()
+			return nil, fmt.Errorf("rapididentity: requested the bytes from %d, the server returned the range %q", offset, res.Header.Get("Content-Range"))
+		}
+		fs.TotalSize = total
+		return fs, nil
+	}
+
+	if res.ContentLength >= 0 {
+		fs.TotalSize = res.ContentLength
+	}
+	if offset > 0 {
+		skipped, err := io.CopyN(io.Discard, res.Body, offset)
+		if err != nil && err != io.EOF {
+			res.Body.Close()
+			return nil, err
+		}
+		if skipped < offset {
+			res.Body.Close()
+			return nil, fmt.Errorf("rapididentity: offset %d is beyond the end of the file of %d bytes", offset, skipped)
+		}
+	}
+
+	return fs, nil
+}
+
+// Parses a Content-Range header such as "bytes 100-199/1000"
+// into the first byte of the range and the total size, which
+// is -1 when unknown as in "bytes 100-199/*".
+func parseContentRange(contentRange string) (int64, int64, bool) {
+	byteRange, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+	byteRange, total, ok := strings.Cut(byteRange, "/")
+	if !ok {
+		return 0, 0, false
+	}
+	first, last, ok := strings.Cut(byteRange, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if end, err := strconv.ParseInt(last, 10, 64); err != nil || end < start {
+		return 0, 0, false
+	}
+	if total == "*" {
+		return start, -1, true
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
@@ -0,0 +1,194 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const streamContent = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+func TestStreamConnectFileContent(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/connect/fileContent/log/job/sync.log", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testHeader(t, r, "Accept", "text/plain")
+		testQueryParam(t, r, "project", "sec_mgr")
+		http.ServeContent(w, r, "sync.log", time.Time{}, strings.NewReader(streamContent))
+	})
+
+	tests := []struct {
+		name   string
+		offset int64
+	}{
+		{"full", 0},
+		{"resumed", 10},
+	}
+
+	for _, tc := range tests {
+		var progress []int64
+		stream, err := client.StreamConnectFileContent(context.Background(), StreamConnectFileContentInput{
+			GetConnectFileContentInput: GetConnectFileContentInput{Path: "log/job/sync.log", Project: "sec_mgr"},
+			Offset:                     tc.offset,
+			Progress: func(transferred int64, total int64) {
+				if total != int64(len(streamContent)) {
+					t.Errorf("%s: progress total: got %d, want %d", tc.name, total, len(streamContent))
+				}
+				progress = append(progress, transferred)
+			},
+		})
+		if err != nil {
+			t.Fatalf("%s: got error %s, want none", tc.name, err)
+		}
+		body, err := io.ReadAll(stream)
+		stream.Close()
+		if err != nil {
+			t.Fatalf("%s: got error %s, want none", tc.name, err)
+		}
+
+		if got, want := string(body), streamContent[tc.offset:]; got != want {
+			t.Errorf("%s: body: got %s, want %s", tc.name, got, want)
+		}
+		if stream.Offset != tc.offset || stream.TotalSize != int64(len(streamContent)) {
+			t.Errorf("%s: got offset %d and size %d", tc.name, stream.Offset, stream.TotalSize)
+		}
+		if len(progress) == 0 || progress[len(progress)-1] != int64(len(streamContent)) {
+			t.Errorf("%s: progress: got %v, want to end at %d", tc.name, progress, len(streamContent))
+		}
+	}
+}
+
+func TestStreamIgnoredRange(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/connect/fileContentZip", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Accept", "application/zip")
+		if !strings.HasPrefix(r.Header.Get("Range"), "bytes=") {
+			t.Errorf("request header Range value: %s, want bytes=<offset>-", r.Header.Get("Range"))
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, streamContent)
+	})
+
+	stream, err := client.StreamConnectFileContentZip(context.Background(), StreamConnectFileContentZipInput{
+		GetConnectFileContentZipInput: GetConnectFileContentZipInput{PathList: StringList{"a.csv", "b.csv"}},
+		Offset:                        10,
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	defer stream.Close()
+	body, _ := io.ReadAll(stream)
+	if got, want := string(body), streamContent[10:]; got != want {
+		t.Errorf("body: got %s, want %s", got, want)
+	}
+	if got, want := stream.ContentType, "application/zip"; got != want {
+		t.Errorf("content type: got %s, want %s", got, want)
+	}
+
+	_, err = client.StreamConnectFileContentZip(context.Background(), StreamConnectFileContentZipInput{Offset: 100})
+	if err == nil {
+		t.Error("offset beyond the end: got no error, want one")
+	}
+}
+
+func TestStreamMismatchedRange(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/connect/fileContent/sync.log", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Range", "bytes=10-")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(streamContent)-1, len(streamContent)))
+		w.WriteHeader(http.StatusPartialContent)
+		fmt.Fprint(w, streamContent)
+	})
+
+	_, err := client.StreamConnectFileContent(context.Background(), StreamConnectFileContentInput{
+		GetConnectFileContentInput: GetConnectFileContentInput{Path: "sync.log"},
+		Offset:                     10,
+	})
+	if err == nil || !strings.Contains(err.Error(), "bytes 0-35/36") {
+		t.Errorf("got error %v, want the mismatched range", err)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		contentRange string
+		start, total int64
+		ok           bool
+	}{
+		{"bytes 100-199/1000", 100, 1000, true},
+		{"bytes 0-0/1", 0, 1, true},
+		{"bytes 100-199/*", 100, -1, true},
+		{"bytes */1000", 0, 0, false},
+		{"bytes 200-100/1000", 0, 0, false},
+		{"items 100-199/1000", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tc := range tests {
+		start, total, ok := parseContentRange(tc.contentRange)
+		if start != tc.start || total != tc.total || ok != tc.ok {
+			t.Errorf("parseContentRange(%q): got %d, %d, %t, want %d, %d, %t", tc.contentRange, start, total, ok, tc.start, tc.total, tc.ok)
+		}
+	}
+}
+
+func TestStreamErrorStatus(t *testing.T) {
+	t.Parallel()
+	client, mux := setup(t)
+	mux.HandleFunc(baseUrlPath+"/admin/connect/fileContent/missing.log", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "file not found"}`)
+	})
+
+	_, err := client.StreamConnectFileContent(context.Background(), StreamConnectFileContentInput{
+		GetConnectFileContentInput: GetConnectFileContentInput{Path: "missing.log"},
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestStreamWithoutResponse(t *testing.T) {
+	t.Parallel()
+	client, _ := setupMiddleware(t, func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			return nil
+		}
+	})
+
+	_, err := client.StreamConnectFileContent(context.Background(), StreamConnectFileContentInput{
+		GetConnectFileContentInput: GetConnectFileContentInput{Path: "sync.log"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no response") {
+		t.Errorf("got error %v, want a missing response error", err)
+	}
+}
+
+func TestNewFileStream(t *testing.T) {
+	t.Parallel()
+	var progress []int64
+	stream := NewFileStream(io.NopCloser(strings.NewReader("log line")), 8, func(transferred, total int64) {
+		progress = append(progress, transferred, total)
+	})
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := string(data), "log line"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := fmt.Sprint(progress), "[8 8]"; got != want {
+		t.Errorf("progress: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,259 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// The number of tenants FanOut calls at
+// once when no concurrency is given.
+const DefaultFanOutConcurrency = 8
+
+// Holds the Options of named tenants and lazily creates
+// one Client per tenant on first use, reusing it, and its
+// session, afterwards. Close closes every created Client.
+//
+//	pool := rapididentity.NewTenantPool(map[string]rapididentity.Options{
+//		"district-a": {BaseUrl: districtA, ServiceIdentity: keyA},
+//		"district-b": {BaseUrl: districtB, ServiceIdentity: keyB},
+//	})
+//	defer pool.Close()
+//
+// A TenantPool is safe for concurrent use.
+type TenantPool struct {
+	mu      sync.Mutex
+	tenants map[string]*pooledTenant
+	closed  bool
+}
+
+type pooledTenant struct {
+	options Options
+
+	// Guards client so that concurrent
+	// first uses create a single Client.
+	mu     sync.Mutex
+	client *Client
+
+	// The number of FanOut calls using client.
+	// A closed tenant closes client when the
+	// last of them returns.
+	refs int
+
+	// Whether the tenant was closed or removed.
+	closed bool
+}
+
+// The result of the function run by FanOut for a tenant.
+type TenantResult[Out any] struct {
+	// The name of the tenant.
+	Tenant string
+
+	// The output of the function.
+	Output Out
+
+	// The error of the function, or of creating
+	// the Client of the tenant.
+	Err error
+}
+
+// Creates a TenantPool with the named tenant Options.
+func NewTenantPool(tenants map[string]Options) *TenantPool {
+	p := &TenantPool{tenants: map[string]*pooledTenant{}}
+	for name, options := range tenants {
+		p.tenants[name] = &pooledTenant{options: options}
+	}
+	return p
+}
+
+// Adds a tenant to the pool. It returns an error if a
+// tenant with the name exists or the pool is closed.
+func (p *TenantPool) Add(name string, options Options) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClientClosed
+	}
+	if _, ok := p.tenants[name]; ok {
+		return fmt.Errorf("rapididentity: tenant %s already exists", name)
+	}
+	p.tenants[name] = &pooledTenant{options: options}
+	return nil
+}
+
+// Removes a tenant from the pool and closes its Client
+// if it was created. While FanOut runs a function with the
+// Client it is closed when the function returns instead.
+// A Client returned by Client must not be used after it.
+func (p *TenantPool) Remove(name string) error {
+	p.mu.Lock()
+	tenant, ok := p.tenants[name]
+	delete(p.tenants, name)
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return tenant.close()
+}
+
+// Returns the sorted names of the tenants.
+func (p *TenantPool) Names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Sorted(maps.Keys(p.tenants))
+}
+
+// Returns the Client of the tenant, creating it on
+// first use. A failed creation is retried on the next
+// call. The Client must not be closed by the caller.
+func (p *TenantPool) Client(name string) (*Client, error) {
+	tenant, err := p.tenant(name)
+	if err != nil {
+		return nil, err
+	}
+	return tenant.get(name, false)
+}
+
+// Returns the tenant with the name.
+func (p *TenantPool) tenant(name string) (*pooledTenant, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrClientClosed
+	}
+	tenant, ok := p.tenants[name]
+	if !ok {
+		return nil, fmt.Errorf("rapididentity: unknown tenant %s", name)
+	}
+	return tenant, nil
+}
+
+// Closes the created Clients, ending their sessions,
+// like Remove. Later calls to Client fail with
+// ErrClientClosed.
+func (p *TenantPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	tenants := slices.Collect(maps.Values(p.tenants))
+	p.mu.Unlock()
+
+	var errs []error
+	for _, tenant := range tenants {
+		errs = append(errs, tenant.close())
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the Client of the tenant, creating it on first
+// use. When acquire is true the Client stays open until
+// release is called, even if the tenant is closed.
+func (t *pooledTenant) get(name string, acquire bool) (*Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, ErrClientClosed
+	}
+	if t.client == nil {
+		client, err := New(t.options)
+		if err != nil {
+			return nil, fmt.Errorf("rapididentity: tenant %s: %w", name, err)
+		}
+		t.client = client
+	}
+	if acquire {
+		t.refs++
+	}
+	return t.client, nil
+}
+
+// Releases the Client acquired with get, closing
+// it if the tenant was closed in the meantime.
+func (t *pooledTenant) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refs--
+	if t.closed && t.refs == 0 {
+		t.client.Close()
+	}
+}
+
+// Closes the Client of the tenant, or leaves
+// it to release while it is acquired.
+func (t *pooledTenant) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.client == nil || t.refs > 0 {
+		return nil
+	}
+	return t.client.Close()
+}
+
+// Runs the function with the Client of every tenant of the
+// pool, at most concurrency tenants at once, and returns the
+// results sorted by tenant name. A concurrency of 0 or less
+// uses DefaultFanOutConcurrency. Once ctx is done, tenants
+// that have not started get the context error. A tenant
+// removed or closed while the function runs keeps its
+// Client open until the function returns.
+//
+//	results := rapididentity.FanOut(ctx, pool, 4,
+//		func(ctx context.Context, tenant string, client *rapididentity.Client) (*rapididentity.GetConnectJobsOutput, error) {
+//			return client.GetConnectJobs(ctx, rapididentity.GetConnectJobsInput{})
+//		})
+//	for _, result := range results {
+//		if result.Err != nil {
+//			log.Printf("%s: %s", result.Tenant, result.Err)
+//		}
+//	}
+func FanOut[Out any](ctx context.Context, pool *TenantPool, concurrency int, fn func(ctx context.Context, tenant string, client *Client) (Out, error)) []TenantResult[Out] {
+	if concurrency <= 0 {
+		concurrency = DefaultFanOutConcurrency
+	}
+	names := pool.Names()
+	results := make([]TenantResult[Out], len(names))
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		results[i].Tenant = name
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			tenant, err := pool.tenant(name)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			client, err := tenant.get(name, true)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			defer tenant.release()
+			results[i].Output, results[i].Err = fn(ctx, name, client)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,244 @@
+package rapididentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type tenantServer struct {
+	url      *url.URL
+	sessions atomic.Int32
+	deletes  atomic.Int32
+}
+
+// Starts a tenant with a user session and a bootstrapInfo
+// endpoint reporting the tenant name. The handler, if not
+// nil, runs before each bootstrapInfo response.
+func newTenantServer(t *testing.T, name string, handler func()) *tenantServer {
+	t.Helper()
+	ts := &tenantServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+baseUrlPath+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		ts.sessions.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"session": {"id": "%s", "token": "token-%s"}}`, name, name)
+	})
+	mux.HandleFunc("DELETE "+baseUrlPath+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		ts.deletes.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc(baseUrlPath+"/bootstrapInfo", func(w http.ResponseWriter, r *http.Request) {
+		if handler != nil {
+			handler()
+		}
+		if r.Header.Get("Authorization") != "Bearer token-"+name {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"tenantId": "%s"}`, name)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	ts.url, _ = url.Parse(server.URL)
+	return ts
+}
+
+func tenantOptions(ts *tenantServer) Options {
+	return Options{
+		BaseUrl: ts.url,
+		RapidIdentityUser: &RapidIdentityUser{
+			Username: mockUsername,
+			Password: mockPassword,
+		},
+	}
+}
+
+func TestTenantPoolReusesClients(t *testing.T) {
+	t.Parallel()
+	a := newTenantServer(t, "a", nil)
+	b := newTenantServer(t, "b", nil)
+	pool := NewTenantPool(map[string]Options{"a": tenantOptions(a)})
+	if err := pool.Add("b", tenantOptions(b)); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if err := pool.Add("b", tenantOptions(b)); err == nil {
+		t.Error("adding existing tenant: got no error, want one")
+	}
+
+	var wg sync.WaitGroup
+	clients := make([]*Client, 10)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := pool.Client("a")
+			if err != nil {
+				t.Errorf("got error %s, want none", err)
+			}
+			clients[i] = client
+		}()
+	}
+	wg.Wait()
+	for _, client := range clients {
+		if client != clients[0] {
+			t.Fatal("got different clients for the same tenant, want one")
+		}
+	}
+	if got, want := a.sessions.Load(), int32(1); got != want {
+		t.Errorf("sessions created: got %d, want %d", got, want)
+	}
+	if _, err := pool.Client("missing"); err == nil {
+		t.Error("unknown tenant: got no error, want one")
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := a.deletes.Load(), int32(1); got != want {
+		t.Errorf("sessions deleted: got %d, want %d", got, want)
+	}
+	if got := b.deletes.Load(); got != 0 {
+		t.Errorf("sessions deleted for unused tenant: got %d, want 0", got)
+	}
+	if _, err := pool.Client("a"); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("after Close: got error %v, want ErrClientClosed", err)
+	}
+}
+
+func TestTenantPoolRemoveDuringFanOut(t *testing.T) {
+	t.Parallel()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	a := newTenantServer(t, "a", func() {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+	})
+	pool := NewTenantPool(map[string]Options{"a": tenantOptions(a)})
+	defer pool.Close()
+
+	var client *Client
+	done := make(chan []TenantResult[string])
+	go func() {
+		done <- FanOut(context.Background(), pool, 0, func(ctx context.Context, tenant string, c *Client) (string, error) {
+			client = c
+			if _, err := c.GetBootstrapInfo(ctx); err != nil {
+				return "", err
+			}
+			output, err := c.GetBootstrapInfo(ctx)
+			if err != nil {
+				return "", err
+			}
+			return output.TenantId, nil
+		})
+	}()
+
+	<-started
+	if err := pool.Remove("a"); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got := a.deletes.Load(); got != 0 {
+		t.Errorf("sessions deleted during FanOut: got %d, want 0", got)
+	}
+	close(release)
+
+	results := <-done
+	if len(results) != 1 || results[0].Err != nil || results[0].Output != "a" {
+		t.Fatalf("got results %+v, want the output of a", results)
+	}
+	if got, want := a.deletes.Load(), int32(1); got != want {
+		t.Errorf("sessions deleted after FanOut: got %d, want %d", got, want)
+	}
+	if _, err := client.GetBootstrapInfo(context.Background()); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("after FanOut: got error %v, want ErrClientClosed", err)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	t.Parallel()
+	var running, maxRunning atomic.Int32
+	track := func() {
+		n := running.Add(1)
+		for {
+			max := maxRunning.Load()
+			if n <= max || maxRunning.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+	}
+
+	tenants := map[string]Options{}
+	for _, name := range []string{"e", "d", "c", "b", "a"} {
+		tenants[name] = tenantOptions(newTenantServer(t, name, track))
+	}
+	missing := *tenants["a"].BaseUrl
+	missing.Path = "/missing"
+	tenants["broken"] = Options{BaseUrl: &missing, RapidIdentityUser: &RapidIdentityUser{}}
+	pool := NewTenantPool(tenants)
+	defer pool.Close()
+
+	results := FanOut(context.Background(), pool, 2,
+		func(ctx context.Context, tenant string, client *Client) (*GetBootstrapInfoOutput, error) {
+			return client.GetBootstrapInfo(ctx)
+		})
+
+	want := []string{"a", "b", "broken", "c", "d", "e"}
+	if got := len(results); got != len(want) {
+		t.Fatalf("results: got %d, want %d", got, len(want))
+	}
+	for i, result := range results {
+		if result.Tenant != want[i] {
+			t.Errorf("result %d: got tenant %s, want %s", i, result.Tenant, want[i])
+		}
+		if result.Tenant == "broken" {
+			if result.Err == nil {
+				t.Error("broken tenant: got no error, want one")
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("%s: got error %s, want none", result.Tenant, result.Err)
+			continue
+		}
+		if result.Output.TenantId != result.Tenant {
+			t.Errorf("%s: got tenant id %s", result.Tenant, result.Output.TenantId)
+		}
+	}
+	if got := maxRunning.Load(); got > 2 {
+		t.Errorf("concurrent calls: got %d, want at most 2", got)
+	}
+}
+
+func TestFanOutCanceled(t *testing.T) {
+	t.Parallel()
+	pool := NewTenantPool(map[string]Options{
+		"a": tenantOptions(newTenantServer(t, "a", nil)),
+		"b": tenantOptions(newTenantServer(t, "b", nil)),
+	})
+	defer pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := FanOut(ctx, pool, 1,
+		func(ctx context.Context, tenant string, client *Client) (*GetBootstrapInfoOutput, error) {
+			return client.GetBootstrapInfo(ctx)
+		})
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("%s: got error %v, want context.Canceled", result.Tenant, result.Err)
+		}
+	}
+}
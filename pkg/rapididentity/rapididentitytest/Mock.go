@@ -0,0 +1,393 @@
+package rapididentitytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+// Returned, wrapped, by the methods of a Mock
+// whose function is not set.
+var ErrNotMocked = errors.New("rapididentitytest: method not mocked")
+
+// A method call recorded by a Mock.
+type MockCall struct {
+	// The name of the method, for example GetUserById.
+	Method string
+
+	// The params of the call, for example a
+	// rapididentity.GetUserByIdInput. Nil for
+	// methods without params.
+	Input any
+}
+
+// The params of DoCustomRequest and
+// DoCustomRequestWithHeaders calls.
+type DoCustomRequestInput struct {
+	Method  string
+	Path    string
+	Headers http.Header
+}
+
+// A mock rapididentity.Api recording every call. Each method
+// calls the function of the field named after it, so tests
+// script outputs by setting the fields they need. Unset API
+// methods return an error wrapping ErrNotMocked, while Session,
+// IsProxy, InvalidateCache, Close and CloseContext return zero
+// values.
+//
+//	mock := &rapididentitytest.Mock{
+//		GetUserByIdFunc: func(ctx context.Context, params rapididentity.GetUserByIdInput) (*rapididentity.User, error) {
+//			return &rapididentity.User{Id: params.Id}, nil
+//		},
+//	}
+//	service := NewService(mock)
+//
+// The fields must not be changed while the Mock is in use.
+type Mock struct {
+	GetConnectActionsFunc                func(ctx context.Context, params rapididentity.GetConnectActionsInput) (*rapididentity.GetConnectActionsOutput, error)
+	GetConnectActionByIdFunc             func(ctx context.Context, params rapididentity.GetConnectActionByIdInput) (*rapididentity.GetConnectActionByIdOutput, error)
+	GetConnectFileContentFunc            func(ctx context.Context, params rapididentity.GetConnectFileContentInput) ([]byte, error)
+	GetConnectFileContentZipFunc         func(ctx context.Context, params rapididentity.GetConnectFileContentZipInput) ([]byte, error)
+	StreamConnectFileContentFunc         func(ctx context.Context, params rapididentity.StreamConnectFileContentInput) (*rapididentity.FileStream, error)
+	StreamConnectFileContentZipFunc      func(ctx context.Context, params rapididentity.StreamConnectFileContentZipInput) (*rapididentity.FileStream, error)
+	GetConnectFilesFunc                  func(ctx context.Context, params rapididentity.GetConnectFilesInput) (*rapididentity.GetConnectFilesOutput, error)
+	GetConnectJobsFunc                   func(ctx context.Context, params rapididentity.GetConnectJobsInput) (*rapididentity.GetConnectJobsOutput, error)
+	GetConnectProjectsFunc               func(ctx context.Context) (*rapididentity.GetConnectProjectsOutput, error)
+	SearchConnectActionSetsFunc          func(ctx context.Context, params rapididentity.SearchConnectActionSetsInput) (*rapididentity.SearchConnectActionSetsOutput, error)
+	SaveConnectActionFunc                func(ctx context.Context, params rapididentity.SaveConnectActionInput) (*rapididentity.SaveConnectActionOutput, error)
+	RunConnectActionFunc                 func(ctx context.Context, params rapididentity.RunConnectActionInput) (*rapididentity.RunConnectActionOutput, error)
+	DeleteConnectActionByIdFunc          func(ctx context.Context, params rapididentity.DeleteConnectActionByIdInput) (*rapididentity.DeleteConnectActionByIdOutput, error)
+	GetDelegationsForUserFunc            func(ctx context.Context, params rapididentity.GetDelegationsForUserInput) (*rapididentity.GetDelegationsForUserOutput, error)
+	GetUserByIdFunc                      func(ctx context.Context, params rapididentity.GetUserByIdInput) (*rapididentity.User, error)
+	RunUserQueryFunc                     func(ctx context.Context, params rapididentity.RunUserQueryInput) (rapididentity.UserList, error)
+	SetPasswordFunc                      func(ctx context.Context, params rapididentity.SetPasswordInput) (rapididentity.SetPasswordOutput, error)
+	GetPasswordPoliciesForFunc           func(ctx context.Context, params rapididentity.GetPasswordPoliciesForInput) (*rapididentity.PasswordPolicy, error)
+	RunAuditReportFunc                   func(ctx context.Context, params rapididentity.RunAuditReportInput) (*rapididentity.RunAuditReportOutput, error)
+	GetAuthenticationPoliciesForUserFunc func(ctx context.Context, params rapididentity.GetAuthenticationPoliciesForUserInput) (*rapididentity.GetAuthenticationPoliciesForUserOutput, error)
+	GetBootstrapInfoFunc                 func(ctx context.Context) (*rapididentity.GetBootstrapInfoOutput, error)
+	GetRapidIdentityAttributesFunc       func(ctx context.Context) (rapididentity.StringList, error)
+	GetCurrentSessionFunc                func(ctx context.Context) (*rapididentity.Session, error)
+	ListSessionsForUserFunc              func(ctx context.Context, params rapididentity.ListSessionsForUserInput) (*rapididentity.ListSessionsForUserOutput, error)
+	RevokeSessionFunc                    func(ctx context.Context, params rapididentity.RevokeSessionInput) error
+	RevokeSessionsForUserFunc            func(ctx context.Context, params rapididentity.RevokeSessionsForUserInput) error
+
+	DoCustomRequestFunc            func(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error)
+	DoCustomRequestWithHeadersFunc func(ctx context.Context, method string, path string, headers http.Header, body io.Reader) (*http.Response, error)
+	SessionFunc                    func() *rapididentity.Session
+	InvalidateCacheFunc            func(operations ...string) error
+	IsProxyFunc                    func() bool
+	CloseFunc                      func() error
+	CloseContextFunc               func(ctx context.Context) error
+
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+var _ rapididentity.Api = (*Mock)(nil)
+
+// Returns the recorded calls in order.
+func (m *Mock) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := make([]MockCall, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// Returns the recorded calls of the method in order.
+func (m *Mock) CallsTo(method string) []MockCall {
+	var calls []MockCall
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Forgets the recorded calls.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *Mock) record(method string, input any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, MockCall{Method: method, Input: input})
+}
+
+func notMocked(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotMocked, method)
+}
+
+func (m *Mock) GetConnectActions(ctx context.Context, params rapididentity.GetConnectActionsInput) (*rapididentity.GetConnectActionsOutput, error) {
+	m.record("GetConnectActions", params)
+	if m.GetConnectActionsFunc == nil {
+		return nil, notMocked("GetConnectActions")
+	}
+	return m.GetConnectActionsFunc(ctx, params)
+}
+
+func (m *Mock) GetConnectActionById(ctx context.Context, params rapididentity.GetConnectActionByIdInput) (*rapididentity.GetConnectActionByIdOutput, error) {
+	m.record("GetConnectActionById", params)
+	if m.GetConnectActionByIdFunc == nil {
+		return nil, notMocked("GetConnectActionById")
+	}
+	return m.GetConnectActionByIdFunc(ctx, params)
+}
+
+func (m *Mock) GetConnectFileContent(ctx context.Context, params rapididentity.GetConnectFileContentInput) ([]byte, error) {
+	m.record("GetConnectFileContent", params)
+	if m.GetConnectFileContentFunc == nil {
+		return nil, notMocked("GetConnectFileContent")
+	}
+	return m.GetConnectFileContentFunc(ctx, params)
+}
+
+func (m *Mock) GetConnectFileContentZip(ctx context.Context, params rapididentity.GetConnectFileContentZipInput) ([]byte, error) {
+	m.record("GetConnectFileContentZip", params)
+	if m.GetConnectFileContentZipFunc == nil {
+		return nil, notMocked("GetConnectFileContentZip")
+	}
+	return m.GetConnectFileContentZipFunc(ctx, params)
+}
+
+func (m *Mock) StreamConnectFileContent(ctx context.Context, params rapididentity.StreamConnectFileContentInput) (*rapididentity.FileStream, error) {
+	m.record("StreamConnectFileContent", params)
+	if m.StreamConnectFileContentFunc == nil {
+		return nil, notMocked("StreamConnectFileContent")
+	}
+	return m.StreamConnectFileContentFunc(ctx, params)
+}
+
+func (m *Mock) StreamConnectFileContentZip(ctx context.Context, params rapididentity.StreamConnectFileContentZipInput) (*rapididentity.FileStream, error) {
+	m.record("StreamConnectFileContentZip", params)
+	if m.StreamConnectFileContentZipFunc == nil {
+		return nil, notMocked("StreamConnectFileContentZip")
+	}
+	return m.StreamConnectFileContentZipFunc(ctx, params)
+}
+
+func (m *Mock) GetConnectFiles(ctx context.Context, params rapididentity.GetConnectFilesInput) (*rapididentity.GetConnectFilesOutput, error) {
+	m.record("GetConnectFiles", params)
+	if m.GetConnectFilesFunc == nil {
+		return nil, notMocked("GetConnectFiles")
+	}
+	return m.GetConnectFilesFunc(ctx, params)
+}
+
+func (m *Mock) GetConnectJobs(ctx context.Context, params rapididentity.GetConnectJobsInput) (*rapididentity.GetConnectJobsOutput, error) {
+	m.record("GetConnectJobs", params)
+	if m.GetConnectJobsFunc == nil {
+		return nil, notMocked("GetConnectJobs")
+	}
+	return m.GetConnectJobsFunc(ctx, params)
+}
+
+func (m *Mock) GetConnectProjects(ctx context.Context) (*rapididentity.GetConnectProjectsOutput, error) {
+	m.record("GetConnectProjects", nil)
+	if m.GetConnectProjectsFunc == nil {
+		return nil, notMocked("GetConnectProjects")
+	}
+	return m.GetConnectProjectsFunc(ctx)
+}
+
+func (m *Mock) SearchConnectActionSets(ctx context.Context, params rapididentity.SearchConnectActionSetsInput) (*rapididentity.SearchConnectActionSetsOutput, error) {
+	m.record("SearchConnectActionSets", params)
+	if m.SearchConnectActionSetsFunc == nil {
+		return nil, notMocked("SearchConnectActionSets")
+	}
+	return m.SearchConnectActionSetsFunc(ctx, params)
+}
+
+func (m *Mock) SaveConnectAction(ctx context.Context, params rapididentity.SaveConnectActionInput) (*rapididentity.SaveConnectActionOutput, error) {
+	m.record("SaveConnectAction", params)
+	if m.SaveConnectActionFunc == nil {
+		return nil, notMocked("SaveConnectAction")
+	}
+	return m.SaveConnectActionFunc(ctx, params)
+}
+
+func (m *Mock) RunConnectAction(ctx context.Context, params rapididentity.RunConnectActionInput) (*rapididentity.RunConnectActionOutput, error) {
+	m.record("RunConnectAction", params)
+	if m.RunConnectActionFunc == nil {
+		return nil, notMocked("RunConnectAction")
+	}
+	return m.RunConnectActionFunc(ctx, params)
+}
+
+func (m *Mock) DeleteConnectActionById(ctx context.Context, params rapididentity.DeleteConnectActionByIdInput) (*rapididentity.DeleteConnectActionByIdOutput, error) {
+	m.record("DeleteConnectActionById", params)
+	if m.DeleteConnectActionByIdFunc == nil {
+		return nil, notMocked("DeleteConnectActionById")
+	}
+	return m.DeleteConnectActionByIdFunc(ctx, params)
+}
+
+func (m *Mock) GetDelegationsForUser(ctx context.Context, params rapididentity.GetDelegationsForUserInput) (*rapididentity.GetDelegationsForUserOutput, error) {
+	m.record("GetDelegationsForUser", params)
+	if m.GetDelegationsForUserFunc == nil {
+		return nil, notMocked("GetDelegationsForUser")
+	}
+	return m.GetDelegationsForUserFunc(ctx, params)
+}
+
+func (m *Mock) GetUserById(ctx context.Context, params rapididentity.GetUserByIdInput) (*rapididentity.User, error) {
+	m.record("GetUserById", params)
+	if m.GetUserByIdFunc == nil {
+		return nil, notMocked("GetUserById")
+	}
+	return m.GetUserByIdFunc(ctx, params)
+}
+
+func (m *Mock) RunUserQuery(ctx context.Context, params rapididentity.RunUserQueryInput) (rapididentity.UserList, error) {
+	m.record("RunUserQuery", params)
+	if m.RunUserQueryFunc == nil {
+		return nil, notMocked("RunUserQuery")
+	}
+	return m.RunUserQueryFunc(ctx, params)
+}
+
+func (m *Mock) SetPassword(ctx context.Context, params rapididentity.SetPasswordInput) (rapididentity.SetPasswordOutput, error) {
+	m.record("SetPassword", params)
+	if m.SetPasswordFunc == nil {
+		return nil, notMocked("SetPassword")
+	}
+	return m.SetPasswordFunc(ctx, params)
+}
+
+func (m *Mock) GetPasswordPoliciesFor(ctx context.Context, params rapididentity.GetPasswordPoliciesForInput) (*rapididentity.PasswordPolicy, error) {
+	m.record("GetPasswordPoliciesFor", params)
+	if m.GetPasswordPoliciesForFunc == nil {
+		return nil, notMocked("GetPasswordPoliciesFor")
+	}
+	return m.GetPasswordPoliciesForFunc(ctx, params)
+}
+
+func (m *Mock) RunAuditReport(ctx context.Context, params rapididentity.RunAuditReportInput) (*rapididentity.RunAuditReportOutput, error) {
+	m.record("RunAuditReport", params)
+	if m.RunAuditReportFunc == nil {
+		return nil, notMocked("RunAuditReport")
+	}
+	return m.RunAuditReportFunc(ctx, params)
+}
+
+func (m *Mock) GetAuthenticationPoliciesForUser(ctx context.Context, params rapididentity.GetAuthenticationPoliciesForUserInput) (*rapididentity.GetAuthenticationPoliciesForUserOutput, error) {
+	m.record("GetAuthenticationPoliciesForUser", params)
+	if m.GetAuthenticationPoliciesForUserFunc == nil {
+		return nil, notMocked("GetAuthenticationPoliciesForUser")
+	}
+	return m.GetAuthenticationPoliciesForUserFunc(ctx, params)
+}
+
+func (m *Mock) GetBootstrapInfo(ctx context.Context) (*rapididentity.GetBootstrapInfoOutput, error) {
+	m.record("GetBootstrapInfo", nil)
+	if m.GetBootstrapInfoFunc == nil {
+		return nil, notMocked("GetBootstrapInfo")
+	}
+	return m.GetBootstrapInfoFunc(ctx)
+}
+
+func (m *Mock) GetRapidIdentityAttributes(ctx context.Context) (rapididentity.StringList, error) {
+	m.record("GetRapidIdentityAttributes", nil)
+	if m.GetRapidIdentityAttributesFunc == nil {
+		return nil, notMocked("GetRapidIdentityAttributes")
+	}
+	return m.GetRapidIdentityAttributesFunc(ctx)
+}
+
+func (m *Mock) GetCurrentSession(ctx context.Context) (*rapididentity.Session, error) {
+	m.record("GetCurrentSession", nil)
+	if m.GetCurrentSessionFunc == nil {
+		return nil, notMocked("GetCurrentSession")
+	}
+	return m.GetCurrentSessionFunc(ctx)
+}
+
+func (m *Mock) ListSessionsForUser(ctx context.Context, params rapididentity.ListSessionsForUserInput) (*rapididentity.ListSessionsForUserOutput, error) {
+	m.record("ListSessionsForUser", params)
+	if m.ListSessionsForUserFunc == nil {
+		return nil, notMocked("ListSessionsForUser")
+	}
+	return m.ListSessionsForUserFunc(ctx, params)
+}
+
+func (m *Mock) RevokeSession(ctx context.Context, params rapididentity.RevokeSessionInput) error {
+	m.record("RevokeSession", params)
+	if m.RevokeSessionFunc == nil {
+		return notMocked("RevokeSession")
+	}
+	return m.RevokeSessionFunc(ctx, params)
+}
+
+func (m *Mock) RevokeSessionsForUser(ctx context.Context, params rapididentity.RevokeSessionsForUserInput) error {
+	m.record("RevokeSessionsForUser", params)
+	if m.RevokeSessionsForUserFunc == nil {
+		return notMocked("RevokeSessionsForUser")
+	}
+	return m.RevokeSessionsForUserFunc(ctx, params)
+}
+
+func (m *Mock) DoCustomRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
+	m.record("DoCustomRequest", DoCustomRequestInput{Method: method, Path: path})
+	if m.DoCustomRequestFunc == nil {
+		return nil, notMocked("DoCustomRequest")
+	}
+	return m.DoCustomRequestFunc(ctx, method, path, body)
+}
+
+func (m *Mock) DoCustomRequestWithHeaders(ctx context.Context, method string, path string, headers http.Header, body io.Reader) (*http.Response, error) {
+	m.record("DoCustomRequestWithHeaders", DoCustomRequestInput{Method: method, Path: path, Headers: headers})
+	if m.DoCustomRequestWithHeadersFunc == nil {
+		return nil, notMocked("DoCustomRequestWithHeaders")
+	}
+	return m.DoCustomRequestWithHeadersFunc(ctx, method, path, headers, body)
+}
+
+func (m *Mock) Session() *rapididentity.Session {
+	m.record("Session", nil)
+	if m.SessionFunc == nil {
+		return nil
+	}
+	return m.SessionFunc()
+}
+
+func (m *Mock) InvalidateCache(operations ...string) error {
+	m.record("InvalidateCache", operations)
+	if m.InvalidateCacheFunc == nil {
+		return nil
+	}
+	return m.InvalidateCacheFunc(operations...)
+}
+
+func (m *Mock) IsProxy() bool {
+	m.record("IsProxy", nil)
+	if m.IsProxyFunc == nil {
+		return false
+	}
+	return m.IsProxyFunc()
+}
+
+func (m *Mock) Close() error {
+	m.record("Close", nil)
+	if m.CloseFunc == nil {
+		return nil
+	}
+	return m.CloseFunc()
+}
+
+func (m *Mock) CloseContext(ctx context.Context) error {
+	m.record("CloseContext", nil)
+	if m.CloseContextFunc == nil {
+		return nil
+	}
+	return m.CloseContextFunc(ctx)
+}
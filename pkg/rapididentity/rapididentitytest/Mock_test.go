@@ -0,0 +1,99 @@
+package rapididentitytest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+// Example of code depending on a narrow interface.
+func deleteActions(ctx context.Context, api rapididentity.ConnectApi, project string) error {
+	actions, err := api.GetConnectActions(ctx, rapididentity.GetConnectActionsInput{Project: project, MetaDataOnly: true})
+	if err != nil {
+		return err
+	}
+	for _, action := range actions.ActionDefs {
+		if _, err := api.DeleteConnectActionById(ctx, rapididentity.DeleteConnectActionByIdInput{Id: action.Id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestMock(t *testing.T) {
+	t.Parallel()
+	mock := &Mock{
+		GetConnectActionsFunc: func(ctx context.Context, params rapididentity.GetConnectActionsInput) (*rapididentity.GetConnectActionsOutput, error) {
+			return &rapididentity.GetConnectActionsOutput{
+				ActionDefs: rapididentity.ActionDefList{{Id: "1234"}, {Id: "5678"}},
+			}, nil
+		},
+		DeleteConnectActionByIdFunc: func(ctx context.Context, params rapididentity.DeleteConnectActionByIdInput) (*rapididentity.DeleteConnectActionByIdOutput, error) {
+			if params.Id == "5678" {
+				return nil, rapididentity.RapidIdentityError{Code: 404}
+			}
+			return &rapididentity.DeleteConnectActionByIdOutput{}, nil
+		},
+	}
+
+	err := deleteActions(context.Background(), mock, "sec_mgr")
+	if !errors.Is(err, rapididentity.ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+
+	calls := mock.Calls()
+	if got, want := len(calls), 3; got != want {
+		t.Fatalf("calls: got %d, want %d", got, want)
+	}
+	if input := calls[0].Input.(rapididentity.GetConnectActionsInput); input.Project != "sec_mgr" || !input.MetaDataOnly {
+		t.Errorf("GetConnectActions input: got %+v", input)
+	}
+	deletes := mock.CallsTo("DeleteConnectActionById")
+	if got, want := deletes[1].Input.(rapididentity.DeleteConnectActionByIdInput).Id, "5678"; got != want {
+		t.Errorf("second delete: got %s, want %s", got, want)
+	}
+
+	mock.Reset()
+	if len(mock.Calls()) != 0 {
+		t.Error("calls after Reset: got some, want none")
+	}
+}
+
+func TestMockNotMocked(t *testing.T) {
+	t.Parallel()
+	var api rapididentity.Api = &Mock{}
+
+	if _, err := api.GetBootstrapInfo(context.Background()); !errors.Is(err, ErrNotMocked) {
+		t.Errorf("got error %v, want ErrNotMocked", err)
+	}
+	if err := api.Close(); err != nil {
+		t.Errorf("Close: got error %s, want none", err)
+	}
+	if api.Session() != nil {
+		t.Error("Session: got session, want nil")
+	}
+}
+
+func TestMockStream(t *testing.T) {
+	t.Parallel()
+	mock := &Mock{
+		StreamConnectFileContentFunc: func(ctx context.Context, params rapididentity.StreamConnectFileContentInput) (*rapididentity.FileStream, error) {
+			return rapididentity.NewFileStream(io.NopCloser(strings.NewReader("log line")), 8, nil), nil
+		},
+	}
+
+	var api rapididentity.ConnectApi = mock
+	stream, err := api.StreamConnectFileContent(context.Background(), rapididentity.StreamConnectFileContentInput{})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil || string(data) != "log line" {
+		t.Errorf("got %q, %v, want log line", data, err)
+	}
+}
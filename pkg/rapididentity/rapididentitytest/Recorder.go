@@ -0,0 +1,361 @@
+// Package rapididentitytest provides utilities for testing
+// code that uses the rapididentity package without a
+// RapidIdentity tenant.
+//
+// # Record and Replay
+//
+// A Recorder is an http.RoundTripper that records the
+// requests made to a real tenant into a cassette file and
+// replays them offline, for example in CI. Secrets such as
+// tokens, passwords and the argument values of sensitive
+// Connect action sets are redacted before the cassette is
+// written.
+//
+//	mode := rapididentitytest.ModeReplay
+//	if os.Getenv("RI_RECORD") != "" {
+//		mode = rapididentitytest.ModeRecord
+//	}
+//	recorder, err := rapididentitytest.NewRecorder("testdata/projects.json", mode, nil)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer recorder.Stop()
+//
+//	client, err := rapididentity.New(rapididentity.Options{
+//		HTTPClient:      recorder.Client(),
+//		BaseUrl:         baseUrl,
+//		ServiceIdentity: os.Getenv("RI_KEY"),
+//	})
+//
+// # Fake Server
+//
+// A Server is a fake tenant keeping sessions, Connect action
+// sets, files, users and audit events in memory. It starts
+// from a Seed, built in Go or loaded with LoadSeedFile, and
+// behaves like a tenant for the wrapped endpoints: unknown
+// tokens get 401, missing ids 404 and SaveConnectAction with
+// a stale version 409.
+//
+//	server := rapididentitytest.NewServer(rapididentitytest.Seed{
+//		ServiceIdentities: []string{"key"},
+//		Actions: []rapididentity.ActionDef{
+//			{Id: "1234", Version: 1, Name: "SyncUsers"},
+//		},
+//	})
+//	defer server.Close()
+//
+//	client, err := server.NewClient(rapididentity.Options{ServiceIdentity: "key"})
+//
+// # Mock
+//
+// Code depending on rapididentity.Api, or one of the narrower
+// interfaces such as rapididentity.ConnectApi, can be tested
+// without HTTP using a Mock, which records calls and returns
+// the outputs of the functions set by the test.
+package rapididentitytest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+// Whether a Recorder records or replays.
+type Mode int
+
+const (
+	// Serves the interactions of the cassette and fails
+	// requests that do not match an interaction.
+	ModeReplay Mode = iota
+
+	// Sends the requests to the tenant and writes the
+	// interactions to the cassette on Stop.
+	ModeRecord
+)
+
+// Returned, wrapped in a *url.Error, by the http client
+// of a replaying Recorder when no unused interaction of
+// the cassette matches the request.
+var ErrNoInteraction = errors.New("rapididentitytest: no matching interaction in cassette")
+
+// The recorded interactions written to a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// A recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// A recorded request. The host is not recorded
+// so cassettes replay against any base url.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+
+	// The encoded query with sorted keys.
+	Query string `json:"query,omitempty"`
+
+	// The redacted request body.
+	Body string `json:"body,omitempty"`
+
+	// The encoding of Body, EncodingBase64 for bodies
+	// that are not JSON, or empty.
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+}
+
+// A recorded response.
+type RecordedResponse struct {
+	StatusCode int `json:"statusCode"`
+
+	// The redacted response headers.
+	Header http.Header `json:"header,omitempty"`
+
+	// The redacted response body.
+	Body string `json:"body,omitempty"`
+
+	// The encoding of Body, EncodingBase64 for bodies
+	// that are not JSON, such as zip files, or empty.
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+}
+
+// The BodyEncoding of recorded bodies that are not
+// JSON. JSON bodies are recorded as is so that they
+// can be read and edited in the cassette.
+const EncodingBase64 = "base64"
+
+// Returns the recorded form of a body and its encoding.
+// JSON bodies are redacted with redact, other bodies are
+// encoded in base64 so that binary content survives the
+// cassette.
+func encodeBody(body []byte, redact func(body []byte) string) (string, string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+	if utf8.Valid(body) && json.Valid(body) {
+		return redact(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), EncodingBase64
+}
+
+// Returns the bytes of a recorded body.
+func decodeBody(body string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return nil, fmt.Errorf("rapididentitytest: unknown body encoding %q", encoding)
+}
+
+// An http.RoundTripper recording or
+// replaying a cassette file.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// Creates a Recorder for the cassette file. In ModeReplay
+// the cassette is read from the path. In ModeRecord the
+// requests are sent with the transport, or
+// http.DefaultTransport if nil.
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: transport,
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("rapididentitytest: invalid cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Returns an http client using the Recorder as its
+// transport, for use as rapididentity.Options.HTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Records or replays the request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, outgoing, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeReplay {
+		if outgoing.Body != nil {
+			outgoing.Body.Close()
+		}
+		return r.replay(req, recorded)
+	}
+
+	res, err := r.transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	res.Request = req
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	recordedBody, encoding := encodeBody(resBody, rapididentity.RedactBody)
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode:   res.StatusCode,
+			Header:       rapididentity.RedactHeaders(res.Header),
+			Body:         recordedBody,
+			BodyEncoding: encoding,
+		},
+	})
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+// Serves the first unused interaction matching the
+// method, path, query and redacted body of the request.
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Request != recorded {
+			continue
+		}
+		body, err := decodeBody(interaction.Response.Body, interaction.Response.BodyEncoding)
+		if err != nil {
+			return nil, err
+		}
+		r.used[i] = true
+
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        strconv.Itoa(interaction.Response.StatusCode) + " " + http.StatusText(interaction.Response.StatusCode),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s?%s", ErrNoInteraction, recorded.Method, recorded.Path, recorded.Query)
+}
+
+// Writes the cassette in ModeRecord. In ModeReplay it
+// returns an error if interactions were not replayed.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ModeReplay {
+		unused := 0
+		for _, used := range r.used {
+			if !used {
+				unused++
+			}
+		}
+		if unused > 0 {
+			return fmt.Errorf("rapididentitytest: %d interactions of cassette %s were not replayed", unused, r.path)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Builds the recorded form of the request used both
+// for writing and for matching interactions. Returns the
+// request to send, a clone holding a buffered copy of the
+// body when the body had to be read, so that the request
+// of the caller is left unmodified.
+func recordRequest(req *http.Request) (RecordedRequest, *http.Request, error) {
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query().Encode(),
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return recorded, req, nil
+	}
+
+	outgoing := req
+	var body []byte
+	var err error
+	if req.GetBody != nil {
+		var reqBody io.ReadCloser
+		reqBody, err = req.GetBody()
+		if err != nil {
+			req.Body.Close()
+			return RecordedRequest{}, nil, err
+		}
+		body, err = io.ReadAll(reqBody)
+		reqBody.Close()
+	} else {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		outgoing = req.Clone(req.Context())
+		outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if err != nil {
+		req.Body.Close()
+		return RecordedRequest{}, nil, err
+	}
+	recorded.Body, recorded.BodyEncoding = encodeBody(body, func(body []byte) string {
+		return rapididentity.RedactRequestBody(req, body)
+	})
+
+	return recorded, outgoing, nil
+}
@@ -0,0 +1,222 @@
+package rapididentitytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+const (
+	mockUsername = "rapididentity@example.com"
+	mockPassword = "donottellanyone"
+)
+
+func newTenant(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/rest/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Set-Cookie", "session=secret-cookie")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"session": {"id": "1", "token": "secret-token"}}`)
+	})
+	mux.HandleFunc("/api/rest/admin/connect/actions", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id": "1234", "name": "SetSecret", "sensitive": true, "args": [{"name": "secret", "value": "hunter2"}]}`)
+	})
+	mux.HandleFunc("/api/rest/admin/connect/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"projects": [{"name": "sec_mgr"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func exercise(t *testing.T, httpClient *http.Client, rawUrl string) {
+	t.Helper()
+	baseUrl, _ := url.Parse(rawUrl)
+	client, err := rapididentity.New(rapididentity.Options{
+		HTTPClient: httpClient,
+		BaseUrl:    baseUrl,
+		RapidIdentityUser: &rapididentity.RapidIdentityUser{
+			Username: mockUsername,
+			Password: mockPassword,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+
+	ctx := context.Background()
+	output, err := client.GetConnectProjects(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if len(output.Projects) != 1 || output.Projects[0].Name != "sec_mgr" {
+		t.Errorf("projects: got %+v, want sec_mgr", output.Projects)
+	}
+	input := rapididentity.SaveConnectActionInput{
+		Action: rapididentity.ActionDef{Name: "SetSecret"},
+	}
+	if _, err := client.SaveConnectAction(ctx, input); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+	cassette := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+
+	recorder, err := NewRecorder(cassette, ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, recorder.Client(), newTenant(t).URL)
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{mockPassword, "secret-token", "secret-cookie", "hunter2"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains secret %s", secret)
+		}
+	}
+
+	replayer, err := NewRecorder(cassette, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, replayer.Client(), "https://offline.example.com")
+	if err := replayer.Stop(); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
+
+func TestReplayNoInteraction(t *testing.T) {
+	t.Parallel()
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+	os.WriteFile(cassette, []byte(`{"interactions": [
+		{"request": {"method": "GET", "path": "/api/rest/admin/connect/projects"}, "response": {"statusCode": 200, "body": "{}"}},
+		{"request": {"method": "GET", "path": "/api/rest/bootstrapInfo"}, "response": {"statusCode": 200, "body": "{}"}}
+	]}`), 0o644)
+
+	replayer, err := NewRecorder(cassette, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := replayer.Client()
+
+	res, err := httpClient.Get("https://offline.example.com/api/rest/admin/connect/projects")
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	_, err = httpClient.Get("https://offline.example.com/api/rest/admin/connect/projects")
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("replayed twice: got error %v, want ErrNoInteraction", err)
+	}
+	_, err = httpClient.Get("https://offline.example.com/api/rest/admin/connect/projects?project=sec_mgr")
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("different query: got error %v, want ErrNoInteraction", err)
+	}
+
+	if err := replayer.Stop(); err == nil {
+		t.Error("got no error, want error for unused interaction")
+	}
+}
+
+func TestRecordBinaryBody(t *testing.T) {
+	t.Parallel()
+	zip := []byte{0x50, 0x4b, 0x03, 0x04, 0xff, 0xfe, 0x80, 0x00}
+	upload := []byte{0x89, 0x50, 0x4e, 0x47, 0xff}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/rest/admin/connect/fileContentZip", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !bytes.Equal(body, upload) {
+			t.Errorf("request body: got %x, want %x", body, upload)
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write(zip)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+
+	roundTrip := func(recorder *Recorder, rawUrl string) []byte {
+		t.Helper()
+		// A body without GetBody is read by the Recorder,
+		// which must leave the request unmodified.
+		body := io.NopCloser(struct{ io.Reader }{bytes.NewReader(upload)})
+		req, _ := http.NewRequest("POST", rawUrl+"/api/rest/admin/connect/fileContentZip", body)
+		res, err := recorder.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+		if req.Body != body {
+			t.Error("got the request body replaced, want the request unmodified")
+		}
+		if res.Request != req {
+			t.Error("got the response of another request, want the request")
+		}
+		resBody, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		return resBody
+	}
+
+	recorder, err := NewRecorder(cassette, ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := roundTrip(recorder, server.URL); !bytes.Equal(got, zip) {
+		t.Errorf("recorded: got %x, want %x", got, zip)
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	data, _ := os.ReadFile(cassette)
+	if !strings.Contains(string(data), `"bodyEncoding": "base64"`) {
+		t.Errorf("got cassette %s, want base64 bodies", data)
+	}
+
+	replayer, err := NewRecorder(cassette, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := roundTrip(replayer, "https://offline.example.com"); !bytes.Equal(got, zip) {
+		t.Errorf("replayed: got %x, want %x", got, zip)
+	}
+	if err := replayer.Stop(); err != nil {
+		t.Errorf("got error %s, want none", err)
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	t.Parallel()
+	if _, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay, nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got error %v, want os.ErrNotExist", err)
+	}
+}
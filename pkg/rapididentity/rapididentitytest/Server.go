@@ -0,0 +1,1135 @@
+package rapididentitytest
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+// The data a fake Server starts with. A Seed can be built
+// in Go or loaded from JSON with LoadSeedFile.
+type Seed struct {
+	// The accepted service identity keys.
+	ServiceIdentities []string `json:"serviceIdentities"`
+
+	// The users that can create sessions and
+	// are returned by the user endpoints.
+	Users []SeedUser `json:"users"`
+
+	// The Connect action sets.
+	Actions []rapididentity.ActionDef `json:"actions"`
+
+	// The Connect projects.
+	Projects []rapididentity.ConnectProject `json:"projects"`
+
+	// The Connect jobs.
+	Jobs []rapididentity.ConnectJob `json:"jobs"`
+
+	// The files of the Connect files module.
+	Files []SeedFile `json:"files"`
+
+	// The events returned by audit report queries.
+	AuditEvents []rapididentity.AuditReportResult `json:"auditEvents"`
+
+	// The delegations and profiles of users.
+	// Users without one get an empty delegation.
+	Delegations []rapididentity.AggregatedDelegation `json:"delegations"`
+
+	// The password policy returned for every user.
+	PasswordPolicy rapididentity.PasswordPolicy `json:"passwordPolicy"`
+
+	// The LDAP attributes of the tenant.
+	Attributes []string `json:"attributes"`
+
+	// The bootstrap info of the tenant. The session
+	// info is replaced with the caller's session.
+	BootstrapInfo rapididentity.GetBootstrapInfoOutput `json:"bootstrapInfo"`
+}
+
+// A user of the fake tenant. The User fields are
+// inlined in JSON next to the password.
+type SeedUser struct {
+	rapididentity.User
+
+	// The password used to create sessions.
+	Password string `json:"password"`
+
+	// The RapidIdentity roles of the user sessions.
+	Roles []string `json:"roles"`
+
+	// The steps of the user's authn/v1 login flow in
+	// order, for example a password followed by a TOTP
+	// code. Defaults to a single password step.
+	AuthenticationMethods []SeedAuthenticationMethod `json:"authenticationMethods"`
+}
+
+// A step of the authn/v1 login flow of a SeedUser.
+type SeedAuthenticationMethod struct {
+	// The authentication method type,
+	// for example password or totp.
+	Type string `json:"type"`
+
+	// The fields the step must be answered with, for
+	// example {"code": "123456"} for totp. Password
+	// steps without fields expect the user's password.
+	Fields map[string]string `json:"fields"`
+
+	// The challenge data returned with the step.
+	Challenge json.RawMessage `json:"challenge"`
+}
+
+// A file of the Connect files module.
+type SeedFile struct {
+	// The project of the file.
+	// Empty for the <Main> project.
+	Project string `json:"project"`
+
+	// The slash separated path of the file.
+	// Directories are implied by the paths.
+	Path string `json:"path"`
+
+	// The content of the file.
+	Content string `json:"content"`
+}
+
+// Reads a JSON encoded Seed from the file.
+func LoadSeedFile(path string) (Seed, error) {
+	var seed Seed
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return seed, err
+	}
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return seed, fmt.Errorf("rapididentitytest: invalid seed %s: %w", path, err)
+	}
+	return seed, nil
+}
+
+// A fake RapidIdentity tenant serving the endpoints wrapped
+// by the rapididentity package from in-memory state. Changes
+// made through the API, such as saved action sets or revoked
+// sessions, are visible to later requests.
+//
+// Like a tenant it rejects requests without a valid service
+// identity key or session token, returns 404 for missing ids
+// and 409 when SaveConnectAction is given a stale version.
+type Server struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	identities  []string
+	users       []SeedUser
+	sessions    map[string]*rapididentity.Session
+	logins      map[string]*login
+	actions     []rapididentity.ActionDef
+	projects    []rapididentity.ConnectProject
+	jobs        []rapididentity.ConnectJob
+	files       []SeedFile
+	auditEvents []rapididentity.AuditReportResult
+	delegations []rapididentity.AggregatedDelegation
+	policy      rapididentity.PasswordPolicy
+	attributes  []string
+	bootstrap   rapididentity.GetBootstrapInfoOutput
+}
+
+// Starts a fake tenant with the seed data.
+// Close the Server when done.
+func NewServer(seed Seed) *Server {
+	s := &Server{
+		identities:  slices.Clone(seed.ServiceIdentities),
+		users:       slices.Clone(seed.Users),
+		sessions:    map[string]*rapididentity.Session{},
+		logins:      map[string]*login{},
+		actions:     slices.Clone(seed.Actions),
+		projects:    slices.Clone(seed.Projects),
+		jobs:        slices.Clone(seed.Jobs),
+		files:       slices.Clone(seed.Files),
+		auditEvents: slices.Clone(seed.AuditEvents),
+		delegations: slices.Clone(seed.Delegations),
+		policy:      seed.PasswordPolicy,
+		attributes:  slices.Clone(seed.Attributes),
+		bootstrap:   seed.BootstrapInfo,
+	}
+
+	const api = "/api/rest"
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+api+"/sessions", s.createSession)
+	mux.HandleFunc("GET "+api+"/sessions", s.authorized(s.getCurrentSession))
+	mux.HandleFunc("DELETE "+api+"/sessions", s.authorized(s.deleteSession))
+	mux.HandleFunc("POST "+api+"/sessions/proxy", s.authorized(s.startProxySession))
+	mux.HandleFunc("DELETE "+api+"/sessions/proxy", s.authorized(s.deleteSession))
+	mux.HandleFunc("POST "+api+"/authn/v1/username", s.startLogin)
+	mux.HandleFunc("POST "+api+"/authn/v1/{method}", s.loginStep)
+	mux.HandleFunc("GET "+api+"/admin/sessions/for/{userId}", s.authorized(s.listSessionsForUser))
+	mux.HandleFunc("DELETE "+api+"/admin/sessions/for/{userId}", s.authorized(s.revokeSessionsForUser))
+	mux.HandleFunc("DELETE "+api+"/admin/sessions/{sessionId}", s.authorized(s.revokeSession))
+
+	mux.HandleFunc("GET "+api+"/admin/connect/actions", s.authorized(s.getConnectActions))
+	mux.HandleFunc("POST "+api+"/admin/connect/actions", s.authorized(s.saveConnectAction))
+	mux.HandleFunc("GET "+api+"/admin/connect/actions/{nameOrId}", s.authorized(s.getConnectActionById))
+	mux.HandleFunc("DELETE "+api+"/admin/connect/actions/{nameOrId}", s.authorized(s.deleteConnectActionById))
+	mux.HandleFunc("GET "+api+"/admin/connect/search/actions", s.authorized(s.searchConnectActionSets))
+	mux.HandleFunc("POST "+api+"/admin/connect/run", s.authorized(s.runConnectAction))
+	mux.HandleFunc("GET "+api+"/admin/connect/projects", s.authorized(s.getConnectProjects))
+	mux.HandleFunc("GET "+api+"/admin/connect/jobs", s.authorized(s.getConnectJobs))
+	mux.HandleFunc("GET "+api+"/admin/connect/files/{path...}", s.authorized(s.getConnectFiles))
+	mux.HandleFunc("GET "+api+"/admin/connect/fileContent/{path...}", s.authorized(s.getConnectFileContent))
+	mux.HandleFunc("GET "+api+"/admin/connect/fileContentZip", s.authorized(s.getConnectFileContentZip))
+
+	mux.HandleFunc("POST "+api+"/users", s.authorized(s.runUserQuery))
+	mux.HandleFunc("GET "+api+"/admin/ldap/users/{dnOrId}", s.authorized(s.getUserById))
+	mux.HandleFunc("GET "+api+"/profiles/aggregated/for/{userId}", s.authorized(s.getDelegationsForUser))
+	mux.HandleFunc("POST "+api+"/profiles/actions/password", s.authorized(s.setPassword))
+	mux.HandleFunc("POST "+api+"/profiles/passwordPolicies/for", s.authorized(s.getPasswordPoliciesFor))
+
+	mux.HandleFunc("POST "+api+"/reporting/auditQuery", s.authorized(s.runAuditReport))
+	mux.HandleFunc("GET "+api+"/bootstrapInfo", s.authorized(s.getBootstrapInfo))
+	mux.HandleFunc("GET "+api+"/admin/ldap/schema/attributes", s.authorized(s.getRapidIdentityAttributes))
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no fake endpoint for "+r.Method+" "+r.URL.Path)
+	})
+
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// Returns the base url of the fake tenant,
+// for use as rapididentity.Options.BaseUrl.
+func (s *Server) BaseUrl() *url.URL {
+	baseUrl, _ := url.Parse(s.server.URL)
+	return baseUrl
+}
+
+// Creates a client for the fake tenant. BaseUrl and
+// HTTPClient are set on the options, the credentials
+// are left to the caller.
+func (s *Server) NewClient(options rapididentity.Options) (*rapididentity.Client, error) {
+	options.BaseUrl = s.BaseUrl()
+	options.HTTPClient = s.server.Client()
+	return rapididentity.New(options)
+}
+
+// Creates a client for the fake tenant with the authn/v1
+// login flow of rapididentity.Login. BaseUrl and HTTPClient
+// are set on the options.
+func (s *Server) Login(ctx context.Context, options rapididentity.Options, params rapididentity.LoginInput) (*rapididentity.Client, error) {
+	options.BaseUrl = s.BaseUrl()
+	options.HTTPClient = s.server.Client()
+	return rapididentity.Login(ctx, options, params)
+}
+
+// Returns a copy of the action set with the id
+// as currently stored, for asserting on changes.
+func (s *Server) Action(id string) (rapididentity.ActionDef, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findAction(id)
+	if i < 0 {
+		return rapididentity.ActionDef{}, false
+	}
+	return s.actions[i], true
+}
+
+// Returns the number of active sessions of the user.
+func (s *Server) SessionCount(userId string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, session := range s.sessions {
+		if session.Session.User.Id == userId {
+			count++
+		}
+	}
+	return count
+}
+
+// Shuts down the fake tenant.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// The caller of a request. Session is nil
+// when a service identity is used.
+type caller struct {
+	session *rapididentity.Session
+}
+
+// Rejects requests without a valid service identity
+// key or session token with 401.
+func (s *Server) authorized(handler func(w http.ResponseWriter, r *http.Request, c caller)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		s.mu.Lock()
+		var c caller
+		session, found := s.sessions[token]
+		if found {
+			session.Session.LastUsed = time.Now().UTC()
+			copied := *session
+			c.session = &copied
+		}
+		found = found || slices.Contains(s.identities, token)
+		s.mu.Unlock()
+
+		if !found {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		handler(w, r, c)
+	}
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var credentials rapididentity.RapidIdentityUser
+	if !readJSON(w, r, &credentials) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Username == credentials.Username && user.Password == credentials.Password {
+			writeJSON(w, http.StatusOK, s.newSession(user.User, user.Roles, rapididentity.User{}))
+			return
+		}
+	}
+	writeError(w, http.StatusUnauthorized, "invalid username or password")
+}
+
+// An authn/v1 login flow in progress.
+type login struct {
+	user SeedUser
+
+	// The index of the next authentication method.
+	step int
+}
+
+// A response of the authn/v1 login flow.
+type loginResponse struct {
+	Id                     string              `json:"id,omitempty"`
+	Method                 string              `json:"method,omitempty"`
+	Challenge              json.RawMessage     `json:"challenge,omitempty"`
+	Completed              bool                `json:"completed"`
+	User                   *rapididentity.User `json:"user,omitempty"`
+	AuthenticationPolicies []any               `json:"authenticationPolicies,omitempty"`
+	*rapididentity.Session
+}
+
+// Returns the authentication methods of the user,
+// defaulting to a single password step.
+func authenticationMethods(user SeedUser) []SeedAuthenticationMethod {
+	if len(user.AuthenticationMethods) == 0 {
+		return []SeedAuthenticationMethod{{Type: "password"}}
+	}
+	return user.AuthenticationMethods
+}
+
+// Starts the login flow of the user. With authenticationPolicies
+// the user's methods are returned as a single enabled policy.
+func (s *Server) startLogin(w http.ResponseWriter, r *http.Request) {
+	var input rapididentity.GetAuthenticationPoliciesForUserPayload
+	if !readJSON(w, r, &input) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.users, func(user SeedUser) bool {
+		return user.Username == input.Username
+	})
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user "+input.Username+" not found")
+		return
+	}
+	user := s.users[i]
+	methods := authenticationMethods(user)
+
+	response := loginResponse{User: &user.User}
+	if showPolicies, _ := strconv.ParseBool(r.URL.Query().Get("authenticationPolicies")); showPolicies {
+		policyMethods := []map[string]any{}
+		for _, method := range methods {
+			policyMethods = append(policyMethods, map[string]any{"type": method.Type, "enabled": true})
+		}
+		response.AuthenticationPolicies = []any{map[string]any{
+			"id":      "default",
+			"name":    "Default",
+			"enabled": true,
+			"methods": policyMethods,
+		}}
+	}
+	response.Id = randomId()
+	response.Method = methods[0].Type
+	response.Challenge = methods[0].Challenge
+	s.logins[response.Id] = &login{user: user}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Answers the current step of a login flow, returning the
+// next step or, after the last one, a new session. Each
+// step is given a new id and answered steps can not be
+// repeated.
+func (s *Server) loginStep(w http.ResponseWriter, r *http.Request) {
+	var input map[string]any
+	if !readJSON(w, r, &input) {
+		return
+	}
+	id, _ := input["id"].(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flow, found := s.logins[id]
+	if !found {
+		writeError(w, http.StatusUnauthorized, "invalid authentication id")
+		return
+	}
+	methods := authenticationMethods(flow.user)
+	method := methods[flow.step]
+	if r.PathValue("method") != method.Type {
+		writeError(w, http.StatusBadRequest, "expected authentication method "+method.Type)
+		return
+	}
+	fields := method.Fields
+	if len(fields) == 0 && method.Type == "password" {
+		fields = map[string]string{"password": flow.user.Password}
+	}
+	for key, want := range fields {
+		if got, _ := input[key].(string); got != want {
+			writeError(w, http.StatusUnauthorized, "invalid "+method.Type+" response")
+			return
+		}
+	}
+
+	delete(s.logins, id)
+	flow.step++
+	if flow.step == len(methods) {
+		writeJSON(w, http.StatusOK, loginResponse{
+			Completed: true,
+			Session:   s.newSession(flow.user.User, flow.user.Roles, rapididentity.User{}),
+		})
+		return
+	}
+	next := methods[flow.step]
+	response := loginResponse{Id: randomId(), Method: next.Type, Challenge: next.Challenge}
+	s.logins[response.Id] = flow
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Creates and stores a session. Must be called with s.mu held.
+func (s *Server) newSession(user rapididentity.User, roles []string, realUser rapididentity.User) *rapididentity.Session {
+	now := time.Now().UTC()
+	session := &rapididentity.Session{
+		Session: rapididentity.SessionInfo{
+			Id:       randomId(),
+			Token:    randomId(),
+			User:     user,
+			RealUser: realUser,
+			Roles:    slices.Clone(roles),
+			Created:  now,
+			LastUsed: now,
+		},
+	}
+	s.sessions[session.Session.Token] = session
+	return session
+}
+
+func (s *Server) getCurrentSession(w http.ResponseWriter, r *http.Request, c caller) {
+	if c.session == nil {
+		writeError(w, http.StatusNotFound, "no session for service identity")
+		return
+	}
+	writeJSON(w, http.StatusOK, c.session)
+}
+
+func (s *Server) deleteSession(w http.ResponseWriter, r *http.Request, c caller) {
+	if c.session != nil {
+		s.mu.Lock()
+		delete(s.sessions, c.session.Session.Token)
+		s.mu.Unlock()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) startProxySession(w http.ResponseWriter, r *http.Request, c caller) {
+	var input rapididentity.ProxyAsInput
+	if !readJSON(w, r, &input) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findUser(input.UserId)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user "+input.UserId+" not found")
+		return
+	}
+	var realUser rapididentity.User
+	if c.session != nil {
+		realUser = c.session.Session.User
+	}
+	writeJSON(w, http.StatusOK, s.newSession(s.users[i].User, s.users[i].Roles, realUser))
+}
+
+func (s *Server) listSessionsForUser(w http.ResponseWriter, r *http.Request, c caller) {
+	userId := r.PathValue("userId")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findUser(userId) < 0 {
+		writeError(w, http.StatusNotFound, "user "+userId+" not found")
+		return
+	}
+	sessions := rapididentity.SessionInfoList{}
+	for _, session := range s.sessions {
+		if session.Session.User.Id == userId {
+			info := session.Session
+			info.Token = ""
+			sessions = append(sessions, info)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b rapididentity.SessionInfo) int {
+		return a.Created.Compare(b.Created)
+	})
+	writeJSON(w, http.StatusOK, rapididentity.ListSessionsForUserOutput{Sessions: sessions})
+}
+
+func (s *Server) revokeSessionsForUser(w http.ResponseWriter, r *http.Request, c caller) {
+	userId := r.PathValue("userId")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findUser(userId) < 0 {
+		writeError(w, http.StatusNotFound, "user "+userId+" not found")
+		return
+	}
+	for token, session := range s.sessions {
+		if session.Session.User.Id == userId {
+			delete(s.sessions, token)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) revokeSession(w http.ResponseWriter, r *http.Request, c caller) {
+	sessionId := r.PathValue("sessionId")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, session := range s.sessions {
+		if session.Session.Id == sessionId {
+			delete(s.sessions, token)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "session "+sessionId+" not found")
+}
+
+func (s *Server) getConnectActions(w http.ResponseWriter, r *http.Request, c caller) {
+	query := r.URL.Query()
+	metaDataOnly, _ := strconv.ParseBool(query.Get("metaDataOnly"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	actions := rapididentity.ActionDefList{}
+	for _, action := range s.actions {
+		if !inProject(query, action.Project) {
+			continue
+		}
+		if metaDataOnly {
+			action.ArgDefs = nil
+			action.Actions = nil
+		}
+		actions = append(actions, action)
+	}
+	writeJSON(w, http.StatusOK, rapididentity.GetConnectActionsOutput{Name: "all", ActionDefs: actions})
+}
+
+func (s *Server) getConnectActionById(w http.ResponseWriter, r *http.Request, c caller) {
+	nameOrId := r.PathValue("nameOrId")
+	metaDataOnly, _ := strconv.ParseBool(r.URL.Query().Get("metaDataOnly"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findAction(nameOrId)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "action "+nameOrId+" not found")
+		return
+	}
+	action := s.actions[i]
+	if metaDataOnly {
+		action.ArgDefs = nil
+		action.Actions = nil
+	}
+	writeJSON(w, http.StatusOK, action)
+}
+
+// Creates or updates an action set. Updates must carry the
+// stored version and increment it, like the tenant does.
+func (s *Server) saveConnectAction(w http.ResponseWriter, r *http.Request, c caller) {
+	var action rapididentity.ActionDef
+	if !readJSON(w, r, &action) {
+		return
+	}
+	if action.Id == "" || action.Name == "" {
+		writeError(w, http.StatusBadRequest, "action id and name are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	action.ModifiedMs = time.Now().UnixMilli()
+	if c.session != nil {
+		action.ModifiedBy = c.session.Session.User.Id
+		action.ModifiedByName = c.session.Session.User.Username
+	}
+
+	i := slices.IndexFunc(s.actions, func(stored rapididentity.ActionDef) bool {
+		return stored.Id == action.Id
+	})
+	if i < 0 {
+		action.Version = 1
+		action.ChangeCount = 1
+		s.actions = append(s.actions, action)
+		writeJSON(w, http.StatusOK, action)
+		return
+	}
+
+	stored := s.actions[i]
+	if action.Version != stored.Version {
+		writeError(w, http.StatusConflict, fmt.Sprintf("version %d of action %s is not the current version %d", action.Version, action.Id, stored.Version))
+		return
+	}
+	action.Version = stored.Version + 1
+	action.ChangeCount = stored.ChangeCount + 1
+	s.actions[i] = action
+	writeJSON(w, http.StatusOK, action)
+}
+
+func (s *Server) deleteConnectActionById(w http.ResponseWriter, r *http.Request, c caller) {
+	nameOrId := r.PathValue("nameOrId")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findAction(nameOrId)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "action "+nameOrId+" not found")
+		return
+	}
+	s.actions = slices.Delete(s.actions, i, i+1)
+	writeJSON(w, http.StatusOK, rapididentity.OperationStatus{
+		Success:    true,
+		Message:    "action " + nameOrId + " deleted",
+		HttpStatus: http.StatusOK,
+	})
+}
+
+func (s *Server) searchConnectActionSets(w http.ResponseWriter, r *http.Request, c caller) {
+	query := r.URL.Query()
+	searchString := query.Get("searchString")
+	matchAction, _ := strconv.ParseBool(query.Get("matchAction"))
+	matchCase, _ := strconv.ParseBool(query.Get("matchCase"))
+	isRegex, _ := strconv.ParseBool(query.Get("regex"))
+
+	pattern := searchString
+	if !isRegex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !matchCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid regex: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	actions := rapididentity.ActionDefList{}
+	for _, action := range s.actions {
+		if !inProject(query, action.Project) {
+			continue
+		}
+		// Without matchAction the search
+		// also covers the actions of the set.
+		text := action.Name
+		if !matchAction {
+			body, _ := json.Marshal(action.Actions)
+			text += "\n" + string(body)
+		}
+		if re.MatchString(text) {
+			actions = append(actions, action)
+		}
+	}
+	writeJSON(w, http.StatusOK, rapididentity.SearchConnectActionSetsOutput{
+		Name:       searchString,
+		ActionDefs: actions,
+		HttpStatus: http.StatusOK,
+	})
+}
+
+// Returns an HTML log of the run. The action set
+// of the action must exist unless it is builtin.
+func (s *Server) runConnectAction(w http.ResponseWriter, r *http.Request, c caller) {
+	var action rapididentity.ConnectAction
+	if !readJSON(w, r, &action) {
+		return
+	}
+
+	s.mu.Lock()
+	found := s.findAction(action.Name) >= 0 || s.findAction(action.Project+"."+action.Name) >= 0
+	s.mu.Unlock()
+	if !found && action.Project != "$builtin" {
+		writeError(w, http.StatusNotFound, "action "+action.Name+" not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "<html><body><pre>Running %s\n", html.EscapeString(action.Name))
+	for _, arg := range action.Args {
+		fmt.Fprintf(w, "  %s = %s\n", html.EscapeString(arg.Name), html.EscapeString(arg.Value))
+	}
+	fmt.Fprint(w, "Completed</pre></body></html>")
+}
+
+func (s *Server) getConnectProjects(w http.ResponseWriter, r *http.Request, c caller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, rapididentity.GetConnectProjectsOutput{
+		Projects: slices.Clone(rapididentity.ConnectProjectList(s.projects)),
+	})
+}
+
+func (s *Server) getConnectJobs(w http.ResponseWriter, r *http.Request, c caller) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := rapididentity.ConnectJobList{}
+	for _, job := range s.jobs {
+		if inProject(query, job.Project) {
+			jobs = append(jobs, job)
+		}
+	}
+	writeJSON(w, http.StatusOK, rapididentity.GetConnectJobsOutput{Jobs: jobs})
+}
+
+// Returns the metadata of a file, or of a directory
+// and the entries directly in it.
+func (s *Server) getConnectFiles(w http.ResponseWriter, r *http.Request, c caller) {
+	project := r.URL.Query().Get("project")
+	filePath := strings.Trim(r.PathValue("path"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if file, ok := s.findFile(project, filePath); ok {
+		writeJSON(w, http.StatusOK, rapididentity.GetConnectFilesOutput{FileEntry: fileEntry(project, file.Path, len(file.Content))})
+		return
+	}
+
+	output := rapididentity.GetConnectFilesOutput{
+		FileEntry:   fileEntry(project, filePath, 0),
+		FileEntries: rapididentity.FileEntryList{},
+	}
+	seen := map[string]bool{}
+	for _, file := range s.files {
+		if file.Project != project {
+			continue
+		}
+		rest, ok := strings.CutPrefix(file.Path, filePath+"/")
+		if filePath == "" {
+			rest, ok = file.Path, true
+		}
+		if !ok {
+			continue
+		}
+		name, _, isDir := strings.Cut(rest, "/")
+		entryPath := strings.TrimPrefix(filePath+"/"+name, "/")
+		if seen[entryPath] {
+			continue
+		}
+		seen[entryPath] = true
+		size := len(file.Content)
+		if isDir {
+			size = 0
+		}
+		output.FileEntries = append(output.FileEntries, fileEntry(project, entryPath, size))
+	}
+	if len(seen) == 0 && filePath != "" {
+		writeError(w, http.StatusNotFound, "file "+filePath+" not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, output)
+}
+
+func (s *Server) getConnectFileContent(w http.ResponseWriter, r *http.Request, c caller) {
+	project := r.URL.Query().Get("project")
+	filePath := strings.Trim(r.PathValue("path"), "/")
+
+	s.mu.Lock()
+	file, ok := s.findFile(project, filePath)
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "file "+filePath+" not found")
+		return
+	}
+
+	// ServeContent supports Range requests
+	// for resuming downloads.
+	w.Header().Set("Content-Type", "text/plain")
+	http.ServeContent(w, r, file.Path, time.Time{}, strings.NewReader(file.Content))
+}
+
+func (s *Server) getConnectFileContentZip(w http.ResponseWriter, r *http.Request, c caller) {
+	query := r.URL.Query()
+	project := query.Get("project")
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	s.mu.Lock()
+	for _, filePath := range query["path"] {
+		file, ok := s.findFile(project, strings.Trim(filePath, "/"))
+		if !ok {
+			s.mu.Unlock()
+			writeError(w, http.StatusNotFound, "file "+filePath+" not found")
+			return
+		}
+		entry, err := archive.Create(file.Path)
+		if err == nil {
+			_, err = entry.Write([]byte(file.Content))
+		}
+		if err != nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	s.mu.Unlock()
+	if err := archive.Close(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	http.ServeContent(w, r, "files.zip", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
+
+func (s *Server) runUserQuery(w http.ResponseWriter, r *http.Request, c caller) {
+	var query rapididentity.AuditReportQuery
+	if !readJSON(w, r, &query) {
+		return
+	}
+	limit, err := strconv.Atoi(cmp.Or(r.URL.Query().Get("limit"), "1000"))
+	if err != nil || limit <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := rapididentity.UserList{}
+	for _, user := range s.users {
+		if len(users) == limit {
+			break
+		}
+		if matchQuery(query, user.User) {
+			users = append(users, user.User)
+		}
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) getUserById(w http.ResponseWriter, r *http.Request, c caller) {
+	dnOrId := r.PathValue("dnOrId")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findUser(dnOrId)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user "+dnOrId+" not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.users[i].User)
+}
+
+func (s *Server) getDelegationsForUser(w http.ResponseWriter, r *http.Request, c caller) {
+	userId := r.PathValue("userId")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findUser(userId)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user "+userId+" not found")
+		return
+	}
+	delegation := rapididentity.AggregatedDelegation{Id: userId, User: s.users[i].User}
+	for _, seeded := range s.delegations {
+		if seeded.Id == userId {
+			delegation = seeded
+		}
+	}
+	writeJSON(w, http.StatusOK, rapididentity.GetDelegationsForUserOutput{AggregatedDelegation: delegation})
+}
+
+// Changes the password of the existing targets.
+// Unknown targets are reported as unsuccessful.
+func (s *Server) setPassword(w http.ResponseWriter, r *http.Request, c caller) {
+	var input rapididentity.SetPasswordInput
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if input.NewPassword == "" {
+		writeError(w, http.StatusBadRequest, "newPassword is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	output := rapididentity.SetPasswordOutput{}
+	for _, target := range input.Targets {
+		result := rapididentity.SetPasswordResult{Target: target}
+		if i := s.findUser(target); i >= 0 {
+			s.users[i].Password = input.NewPassword
+			result.Success = true
+			result.TargetName = s.users[i].Username
+		}
+		output = append(output, result)
+	}
+	writeJSON(w, http.StatusOK, output)
+}
+
+func (s *Server) getPasswordPoliciesFor(w http.ResponseWriter, r *http.Request, c caller) {
+	var input rapididentity.GetPasswordPoliciesForInput
+	if !readJSON(w, r, &input) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, userId := range input.UserIds {
+		if s.findUser(userId) < 0 {
+			writeError(w, http.StatusNotFound, "user "+userId+" not found")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, s.policy)
+}
+
+// Runs the query over the audit events. The page
+// token is the offset of the next page.
+func (s *Server) runAuditReport(w http.ResponseWriter, r *http.Request, c caller) {
+	var query rapididentity.AuditReportQuery
+	if !readJSON(w, r, &query) {
+		return
+	}
+	params := r.URL.Query()
+	pageSize, err := strconv.Atoi(cmp.Or(params.Get("page_size"), "0"))
+	if err != nil || pageSize < 0 {
+		writeError(w, http.StatusBadRequest, "invalid page_size")
+		return
+	}
+	offset, err := strconv.Atoi(cmp.Or(params.Get("page_token"), "0"))
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, "invalid page_token")
+		return
+	}
+
+	s.mu.Lock()
+	records := rapididentity.AuditReportResultList{}
+	for _, event := range s.auditEvents {
+		if matchQuery(query, event) {
+			records = append(records, event)
+		}
+	}
+	s.mu.Unlock()
+
+	output := rapididentity.RunAuditReportOutput{}
+	records = records[min(offset, len(records)):]
+	if pageSize > 0 && len(records) > pageSize {
+		records = records[:pageSize]
+		output.NextPageToken = strconv.Itoa(offset + pageSize)
+	}
+	output.AuditLogRecords = records
+	writeJSON(w, http.StatusOK, output)
+}
+
+func (s *Server) getBootstrapInfo(w http.ResponseWriter, r *http.Request, c caller) {
+	s.mu.Lock()
+	output := s.bootstrap
+	s.mu.Unlock()
+	if c.session != nil {
+		output.SessionInfo = c.session.Session
+		output.SessionInfo.Token = ""
+	}
+	writeJSON(w, http.StatusOK, output)
+}
+
+func (s *Server) getRapidIdentityAttributes(w http.ResponseWriter, r *http.Request, c caller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, rapididentity.StringList(slices.Clone(s.attributes)))
+}
+
+// Returns the index of the user with the idautoID
+// or DN, or -1. Must be called with s.mu held.
+func (s *Server) findUser(dnOrId string) int {
+	return slices.IndexFunc(s.users, func(user SeedUser) bool {
+		return user.Id == dnOrId || (user.Dn != "" && user.Dn == dnOrId)
+	})
+}
+
+// Returns the index of the action set with the id or
+// <project>.<name>, or -1. Action sets of the <Main>
+// project can be found by name alone. Must be called
+// with s.mu held.
+func (s *Server) findAction(nameOrId string) int {
+	return slices.IndexFunc(s.actions, func(action rapididentity.ActionDef) bool {
+		if action.Id == nameOrId {
+			return true
+		}
+		if action.Project == "" || action.Project == rapididentity.MainProject {
+			return action.Name == nameOrId
+		}
+		return action.Project+"."+action.Name == nameOrId
+	})
+}
+
+// Returns the file at the path. Must be called with s.mu held.
+func (s *Server) findFile(project string, filePath string) (SeedFile, bool) {
+	for _, file := range s.files {
+		if file.Project == project && strings.Trim(file.Path, "/") == filePath {
+			return file, true
+		}
+	}
+	return SeedFile{}, false
+}
+
+// Reports whether a resource of the project is selected by
+// the project query parameter. No parameter selects all
+// projects and an empty one the <Main> project.
+func inProject(query url.Values, project string) bool {
+	if !query.Has("project") {
+		return true
+	}
+	want := query.Get("project")
+	if want == "" {
+		return project == "" || project == rapididentity.MainProject
+	}
+	return project == want
+}
+
+func fileEntry(project string, filePath string, size int) rapididentity.FileEntry {
+	return rapididentity.FileEntry{
+		Path:     path.Clean("/" + filePath),
+		Size:     size,
+		Project:  project,
+		Readable: true,
+		Writable: true,
+	}
+}
+
+// Reports whether the record matches the query. Field names
+// are the dot separated JSON keys of the record, for example
+// action.displayName. Nodes without an operator match.
+func matchQuery(query rapididentity.AuditReportQuery, record any) bool {
+	switch query.OperatorType {
+	case rapididentity.AND:
+		for _, child := range query.ChildNodes {
+			if !matchQuery(child, record) {
+				return false
+			}
+		}
+		return true
+	case rapididentity.OR:
+		for _, child := range query.ChildNodes {
+			if matchQuery(child, record) {
+				return true
+			}
+		}
+		return len(query.ChildNodes) == 0
+	case "":
+		return true
+	}
+
+	data, _ := json.Marshal(record)
+	var value any
+	json.Unmarshal(data, &value)
+	for _, key := range strings.Split(query.FieldName, ".") {
+		object, _ := value.(map[string]any)
+		value = object[key]
+	}
+	field := fmt.Sprint(value)
+	if value == nil {
+		field = ""
+	}
+
+	switch query.OperatorType {
+	case rapididentity.EQUAL:
+		return strings.EqualFold(field, query.FieldValue)
+	case rapididentity.NOT_EQUAL:
+		return !strings.EqualFold(field, query.FieldValue)
+	case rapididentity.LIKE:
+		return strings.Contains(strings.ToLower(field), strings.ToLower(strings.Trim(query.FieldValue, "*%")))
+	case rapididentity.LESS_THAN:
+		return field < query.FieldValue
+	case rapididentity.GREATER_THAN:
+		return field > query.FieldValue
+	}
+	return false
+}
+
+// Decodes the JSON request body, writing a 400
+// response and returning false if it is invalid.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Writes the JSON error payload of the tenant.
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, rapididentity.ErrorPayload{
+		Message:    message,
+		Error:      http.StatusText(code),
+		HttpStatus: code,
+	})
+}
+
+func randomId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
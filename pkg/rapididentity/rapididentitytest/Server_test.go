@@ -0,0 +1,475 @@
+package rapididentitytest
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity"
+)
+
+const mockServiceIdentity = "service_identity_key"
+
+func testSeed() Seed {
+	return Seed{
+		ServiceIdentities: []string{mockServiceIdentity},
+		Users: []SeedUser{
+			{
+				User:     rapididentity.User{Id: "08b5f0ec", Dn: "idautoID=08b5f0ec,ou=people", Username: mockUsername, FirstName: "Ada"},
+				Password: mockPassword,
+				Roles:    []string{"connectAdmin"},
+				AuthenticationMethods: []SeedAuthenticationMethod{
+					{Type: "password"},
+					{Type: "totp", Fields: map[string]string{"code": "123456"}, Challenge: json.RawMessage(`{"digits":6}`)},
+				},
+			},
+			{
+				User:     rapididentity.User{Id: "5f1b7c2a", Username: "grace@example.com", FirstName: "Grace"},
+				Password: "grace",
+			},
+		},
+		Actions: []rapididentity.ActionDef{
+			{Id: "1234", Version: 3, Name: "SyncUsers", Project: "sec_mgr", Description: "Syncs users"},
+			{Id: "5678", Version: 1, Name: "Cleanup", Description: "Removes stale accounts"},
+		},
+		Projects: []rapididentity.ConnectProject{{Name: "sec_mgr"}},
+		Jobs: []rapididentity.ConnectJob{
+			{Name: "nightly", Project: "sec_mgr"},
+			{Name: "hourly"},
+		},
+		Files: []SeedFile{
+			{Path: "scripts/sync.csv", Content: "id,name\n"},
+			{Path: "scripts/archive/old.csv", Content: "id\n"},
+			{Path: "readme.txt", Content: "hello"},
+		},
+		AuditEvents: []rapididentity.AuditReportResult{
+			{Id: "1", PerpetratorId: "08b5f0ec"},
+			{Id: "2", PerpetratorId: "5f1b7c2a"},
+			{Id: "3", PerpetratorId: "08b5f0ec"},
+			{Id: "4", PerpetratorId: "08b5f0ec"},
+		},
+	}
+}
+
+func newServerClient(t *testing.T, options rapididentity.Options) (*Server, *rapididentity.Client) {
+	t.Helper()
+	server := NewServer(testSeed())
+	t.Cleanup(server.Close)
+	if options.RapidIdentityUser == nil {
+		options.ServiceIdentity = mockServiceIdentity
+	}
+	client, err := server.NewClient(options)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	return server, client
+}
+
+func TestServerUnauthorized(t *testing.T) {
+	t.Parallel()
+	server := NewServer(testSeed())
+	t.Cleanup(server.Close)
+
+	client, err := server.NewClient(rapididentity.Options{ServiceIdentity: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetConnectProjects(context.Background()); !errors.Is(err, rapididentity.ErrUnauthorized) {
+		t.Errorf("got error %v, want ErrUnauthorized", err)
+	}
+
+	_, err = server.NewClient(rapididentity.Options{
+		RapidIdentityUser: &rapididentity.RapidIdentityUser{Username: mockUsername, Password: "wrong"},
+	})
+	if !errors.Is(err, rapididentity.ErrUnauthorized) {
+		t.Errorf("session: got error %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestServerSaveConnectAction(t *testing.T) {
+	t.Parallel()
+	server, client := newServerClient(t, rapididentity.Options{})
+	ctx := context.Background()
+
+	found, err := client.GetConnectActionById(ctx, rapididentity.GetConnectActionByIdInput{Id: "sec_mgr.SyncUsers"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	action := found.Action
+	action.Description = "Syncs all users"
+	saved, err := client.SaveConnectAction(ctx, rapididentity.SaveConnectActionInput{Action: action})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := saved.Action.Version, 4; got != want {
+		t.Errorf("version: got %d, want %d", got, want)
+	}
+
+	_, err = client.SaveConnectAction(ctx, rapididentity.SaveConnectActionInput{Action: action})
+	if !errors.Is(err, rapididentity.ErrVersionConflict) {
+		t.Errorf("stale save: got error %v, want ErrVersionConflict", err)
+	}
+	if stored, _ := server.Action("1234"); stored.Description != "Syncs all users" || stored.Version != 4 {
+		t.Errorf("stored action: got %+v", stored)
+	}
+
+	created, err := client.SaveConnectAction(ctx, rapididentity.SaveConnectActionInput{
+		Action: rapididentity.ActionDef{Id: "9abc", Name: "NewAction", Project: "sec_mgr"},
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := created.Action.Version, 1; got != want {
+		t.Errorf("created version: got %d, want %d", got, want)
+	}
+
+	actions, err := client.GetConnectActions(ctx, rapididentity.GetConnectActionsInput{Project: "sec_mgr"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := len(actions.ActionDefs), 2; got != want {
+		t.Errorf("project actions: got %d, want %d", got, want)
+	}
+}
+
+func TestServerNotFound(t *testing.T) {
+	t.Parallel()
+	_, client := newServerClient(t, rapididentity.Options{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"GetConnectActionById", func() error {
+			_, err := client.GetConnectActionById(ctx, rapididentity.GetConnectActionByIdInput{Id: "missing"})
+			return err
+		}},
+		{"DeleteConnectActionById", func() error {
+			_, err := client.DeleteConnectActionById(ctx, rapididentity.DeleteConnectActionByIdInput{Id: "missing"})
+			return err
+		}},
+		{"GetUserById", func() error {
+			_, err := client.GetUserById(ctx, rapididentity.GetUserByIdInput{Id: "missing"})
+			return err
+		}},
+		{"GetConnectFileContent", func() error {
+			_, err := client.GetConnectFileContent(ctx, rapididentity.GetConnectFileContentInput{Path: "missing.txt"})
+			return err
+		}},
+		{"RevokeSession", func() error {
+			return client.RevokeSession(ctx, rapididentity.RevokeSessionInput{SessionId: "missing"})
+		}},
+	}
+	for _, tc := range tests {
+		if err := tc.call(); !errors.Is(err, rapididentity.ErrNotFound) {
+			t.Errorf("%s: got error %v, want ErrNotFound", tc.name, err)
+		}
+	}
+
+	if _, err := client.DeleteConnectActionById(ctx, rapididentity.DeleteConnectActionByIdInput{Id: "Cleanup"}); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	_, err := client.GetConnectActionById(ctx, rapididentity.GetConnectActionByIdInput{Id: "5678"})
+	if !errors.Is(err, rapididentity.ErrNotFound) {
+		t.Errorf("deleted action: got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestServerAuditReportPaging(t *testing.T) {
+	t.Parallel()
+	_, client := newServerClient(t, rapididentity.Options{})
+	ctx := context.Background()
+
+	input := rapididentity.RunAuditReportInput{
+		Query: rapididentity.AuditReportQuery{
+			FieldName:    "perpetratorId",
+			FieldValue:   "08b5f0ec",
+			OperatorType: rapididentity.EQUAL,
+		},
+		PageSize: 2,
+	}
+	var ids []string
+	for {
+		output, err := client.RunAuditReport(ctx, input)
+		if err != nil {
+			t.Fatalf("got error %s, want none", err)
+		}
+		for _, record := range output.AuditLogRecords {
+			ids = append(ids, record.Id)
+		}
+		if output.NextPageToken == "" {
+			break
+		}
+		input.PageToken = output.NextPageToken
+	}
+	if got, want := strings.Join(ids, ","), "1,3,4"; got != want {
+		t.Errorf("records: got %s, want %s", got, want)
+	}
+}
+
+func TestServerSessions(t *testing.T) {
+	t.Parallel()
+	server, client := newServerClient(t, rapididentity.Options{
+		RapidIdentityUser: &rapididentity.RapidIdentityUser{Username: mockUsername, Password: mockPassword},
+	})
+	ctx := context.Background()
+
+	session, err := client.GetCurrentSession(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := session.Session.User.Id, "08b5f0ec"; got != want {
+		t.Errorf("session user: got %s, want %s", got, want)
+	}
+
+	sessions, err := client.ListSessionsForUser(ctx, rapididentity.ListSessionsForUserInput{UserId: "08b5f0ec"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if len(sessions.Sessions) != 1 || sessions.Sessions[0].Token != "" {
+		t.Errorf("sessions: got %+v, want one without token", sessions.Sessions)
+	}
+
+	// The client renews its revoked session on the next call.
+	if err := client.RevokeSessionsForUser(ctx, rapididentity.RevokeSessionsForUserInput{UserId: "08b5f0ec"}); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if _, err := client.GetConnectProjects(ctx); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := server.SessionCount("08b5f0ec"), 1; got != want {
+		t.Errorf("sessions after renewal: got %d, want %d", got, want)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got := server.SessionCount("08b5f0ec"); got != 0 {
+		t.Errorf("sessions after close: got %d, want 0", got)
+	}
+}
+
+func TestServerLogin(t *testing.T) {
+	t.Parallel()
+	server := NewServer(testSeed())
+	t.Cleanup(server.Close)
+	ctx := context.Background()
+
+	var challenges []rapididentity.Challenge
+	code := "123456"
+	responder := rapididentity.PasswordResponder(mockPassword, func(ctx context.Context, challenge rapididentity.Challenge) (map[string]any, error) {
+		challenges = append(challenges, challenge)
+		return map[string]any{"code": code}, nil
+	})
+	client, err := server.Login(ctx, rapididentity.Options{}, rapididentity.LoginInput{Username: mockUsername, Responder: responder})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if len(challenges) != 1 || challenges[0].Type != "totp" || string(challenges[0].Data) != `{"digits":6}` {
+		t.Fatalf("challenges: got %+v, want one totp step", challenges)
+	}
+	if _, ok := challenges[0].Method.(rapididentity.TotpMethod); !ok {
+		t.Errorf("challenge method: got %T, want rapididentity.TotpMethod", challenges[0].Method)
+	}
+
+	session, err := client.GetCurrentSession(ctx)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := session.Session.User.Id, "08b5f0ec"; got != want {
+		t.Errorf("session user: got %s, want %s", got, want)
+	}
+	if got, want := server.SessionCount("08b5f0ec"), 1; got != want {
+		t.Errorf("sessions: got %d, want %d", got, want)
+	}
+
+	code = "654321"
+	_, err = server.Login(ctx, rapididentity.Options{}, rapididentity.LoginInput{Username: mockUsername, Responder: responder})
+	if !errors.Is(err, rapididentity.ErrUnauthorized) {
+		t.Errorf("wrong code: got error %v, want ErrUnauthorized", err)
+	}
+
+	// Users without authentication methods only need a password.
+	_, err = server.Login(ctx, rapididentity.Options{}, rapididentity.LoginInput{
+		Username:  "grace@example.com",
+		Responder: rapididentity.PasswordResponder("grace", nil),
+	})
+	if err != nil {
+		t.Errorf("password only: got error %s, want none", err)
+	}
+	if got, want := server.SessionCount("5f1b7c2a"), 1; got != want {
+		t.Errorf("password only sessions: got %d, want %d", got, want)
+	}
+}
+
+func TestServerAuthenticationPolicies(t *testing.T) {
+	t.Parallel()
+	_, client := newServerClient(t, rapididentity.Options{})
+
+	output, err := client.GetAuthenticationPoliciesForUser(context.Background(), rapididentity.GetAuthenticationPoliciesForUserInput{
+		ShowAuthenticationPolicies: true,
+		User:                       rapididentity.GetAuthenticationPoliciesForUserPayload{Username: mockUsername},
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := output.User.Id, "08b5f0ec"; got != want {
+		t.Errorf("user: got %s, want %s", got, want)
+	}
+	if len(output.AuthenticationPolicies) != 1 || len(output.AuthenticationPolicies[0].Methods) != 2 {
+		t.Errorf("policies: got %+v, want one with two methods", output.AuthenticationPolicies)
+	}
+}
+
+func TestServerPeople(t *testing.T) {
+	t.Parallel()
+	server, client := newServerClient(t, rapididentity.Options{})
+	ctx := context.Background()
+
+	users, err := client.RunUserQuery(ctx, rapididentity.RunUserQueryInput{
+		Query: rapididentity.AuditReportQuery{FieldName: "firstName", FieldValue: "gra", OperatorType: rapididentity.LIKE},
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if len(users) != 1 || users[0].Id != "5f1b7c2a" {
+		t.Errorf("users: got %+v, want Grace", users)
+	}
+
+	user, err := client.GetUserById(ctx, rapididentity.GetUserByIdInput{Id: "idautoID=08b5f0ec,ou=people"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := user.Username, mockUsername; got != want {
+		t.Errorf("username: got %s, want %s", got, want)
+	}
+
+	results, err := client.SetPassword(ctx, rapididentity.SetPasswordInput{
+		Targets:     rapididentity.StringList{"5f1b7c2a", "missing"},
+		NewPassword: "newpassword",
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if len(results) != 2 || !results[0].Success || results[1].Success {
+		t.Errorf("results: got %+v", results)
+	}
+	_, err = server.NewClient(rapididentity.Options{
+		RapidIdentityUser: &rapididentity.RapidIdentityUser{Username: "grace@example.com", Password: "newpassword"},
+	})
+	if err != nil {
+		t.Errorf("login with new password: got error %s, want none", err)
+	}
+}
+
+func TestServerConnectFiles(t *testing.T) {
+	t.Parallel()
+	_, client := newServerClient(t, rapididentity.Options{})
+	ctx := context.Background()
+
+	dir, err := client.GetConnectFiles(ctx, rapididentity.GetConnectFilesInput{Path: "scripts"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	var paths []string
+	for _, entry := range dir.FileEntries {
+		paths = append(paths, entry.Path)
+	}
+	if got, want := strings.Join(paths, ","), "/scripts/sync.csv,/scripts/archive"; got != want {
+		t.Errorf("entries: got %s, want %s", got, want)
+	}
+
+	content, err := client.GetConnectFileContent(ctx, rapididentity.GetConnectFileContentInput{Path: "scripts/sync.csv"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if got, want := string(content), "id,name\n"; got != want {
+		t.Errorf("content: got %q, want %q", got, want)
+	}
+
+	stream, err := client.StreamConnectFileContent(ctx, rapididentity.StreamConnectFileContentInput{
+		GetConnectFileContentInput: rapididentity.GetConnectFileContentInput{Path: "scripts/sync.csv"},
+		Offset:                     3,
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	resumed, _ := io.ReadAll(stream)
+	stream.Close()
+	if got, want := string(resumed), "name\n"; got != want {
+		t.Errorf("resumed content: got %q, want %q", got, want)
+	}
+
+	archive, err := client.GetConnectFileContentZip(ctx, rapididentity.GetConnectFileContentZipInput{
+		PathList: rapididentity.StringList{"readme.txt", "scripts/archive/old.csv"},
+	})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(reader.File), 2; got != want {
+		t.Errorf("zip files: got %d, want %d", got, want)
+	}
+
+	jobs, err := client.GetConnectJobs(ctx, rapididentity.GetConnectJobsInput{Project: rapididentity.MainProject})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if len(jobs.Jobs) != 1 || jobs.Jobs[0].Name != "hourly" {
+		t.Errorf("jobs: got %+v, want hourly", jobs.Jobs)
+	}
+}
+
+func TestLoadSeedFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "seed.json")
+	seed := `{
+		"serviceIdentities": ["` + mockServiceIdentity + `"],
+		"users": [{"id": "08b5f0ec", "username": "` + mockUsername + `", "password": "` + mockPassword + `"}],
+		"actions": [{"id": "1234", "version": 2, "name": "SyncUsers"}],
+		"bootstrapInfo": {"tenantId": "tenant"}
+	}`
+	if err := os.WriteFile(path, []byte(seed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSeedFile(path)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	server := NewServer(loaded)
+	t.Cleanup(server.Close)
+	client, err := server.NewClient(rapididentity.Options{
+		RapidIdentityUser: &rapididentity.RapidIdentityUser{Username: mockUsername, Password: mockPassword},
+	})
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+
+	info, err := client.GetBootstrapInfo(context.Background())
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if info.TenantId != "tenant" || info.SessionInfo.User.Id != "08b5f0ec" {
+		t.Errorf("bootstrap info: got tenant %s and user %s", info.TenantId, info.SessionInfo.User.Id)
+	}
+
+	result, err := client.SearchConnectActionSets(context.Background(), rapididentity.SearchConnectActionSetsInput{SearchString: "sync"})
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if len(result.ActionDefs) != 1 || result.ActionDefs[0].Version != 2 {
+		t.Errorf("search: got %+v", result.ActionDefs)
+	}
+}
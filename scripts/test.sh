@@ -1,7 +1,7 @@
 #!/bin/bash
 
 cd $(dirname "$0")/..
-go test github.com/hatch-ed-com/ri-sdk-go/pkg/rapididentity || FAILED=1
+go test -race github.com/hatch-ed-com/ri-sdk-go/pkg/... github.com/hatch-ed-com/ri-sdk-go/tools/... || FAILED=1
 
 if [ -n "$FAILED" ]; then
     exit 1
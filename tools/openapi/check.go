@@ -0,0 +1,285 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"slices"
+	"strings"
+)
+
+type Severity string
+
+const (
+	// The annotation does not match the spec.
+	SeverityError Severity = "error"
+
+	// A parameter or field differs from the spec.
+	SeverityWarning Severity = "warning"
+)
+
+// A difference between an annotated function
+// and the spec.
+type Finding struct {
+	Pos        token.Position
+	Severity   Severity
+	Annotation Annotation
+	Message    string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s): %s", f.Pos.Filename, f.Pos.Line, f.Severity, f.Annotation.Func, f.Annotation.Operation(), f.Message)
+}
+
+// The result of checking a package against a spec.
+type Report struct {
+	Findings []Finding
+
+	// The operations of the spec without an annotation.
+	Unwrapped []*SpecOperation
+}
+
+// Reports whether the report has findings of the
+// severity, or of any severity when strict.
+func (r *Report) Failed(strict bool) bool {
+	return slices.ContainsFunc(r.Findings, func(f Finding) bool {
+		return strict || f.Severity == SeverityError
+	})
+}
+
+func (r *Report) Write(w io.Writer) {
+	for _, finding := range r.Findings {
+		fmt.Fprintln(w, finding)
+	}
+	if len(r.Unwrapped) > 0 {
+		fmt.Fprintf(w, "%d unwrapped operations:\n", len(r.Unwrapped))
+		for _, op := range r.Unwrapped {
+			fmt.Fprintf(w, "\t%s %s", op.Method, op.Path)
+			if op.OperationId != "" {
+				fmt.Fprintf(w, " (%s)", op.OperationId)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// Checks every annotation of the package against the
+// spec: the path and method must exist, the query
+// parameters must be fields of the input, and the fields
+// of the input and output types must match the request
+// and response schemas.
+func Check(pkg *Package, spec *Spec) *Report {
+	report := &Report{}
+	wrapped := map[string]bool{}
+	for _, annotation := range pkg.Annotations {
+		wrapped[operationKey(annotation.Method, annotation.Path)] = true
+		c := &checker{pkg: pkg, spec: spec, annotation: annotation}
+		c.check()
+		report.Findings = append(report.Findings, c.findings...)
+	}
+	for _, op := range spec.Operations() {
+		if !wrapped[operationKey(op.Method, op.Path)] {
+			report.Unwrapped = append(report.Unwrapped, op)
+		}
+	}
+	return report
+}
+
+type checker struct {
+	pkg        *Package
+	spec       *Spec
+	annotation Annotation
+	findings   []Finding
+}
+
+func (c *checker) report(severity Severity, format string, args ...any) {
+	c.findings = append(c.findings, Finding{
+		Pos:        c.annotation.Pos,
+		Severity:   severity,
+		Annotation: c.annotation,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) check() {
+	a := c.annotation
+	op := c.spec.Operation(a.Method, a.Path)
+	if op == nil {
+		if methods := c.spec.Methods(a.Path); len(methods) > 0 {
+			c.report(SeverityError, "method %s is not in the spec, the path has %s", a.Method, strings.Join(methods, ", "))
+		} else {
+			c.report(SeverityError, "path %s is not in the spec", a.Path)
+		}
+		return
+	}
+
+	specParams := pathParams(op.Path)
+	for i, name := range pathParams(a.Path) {
+		if i < len(specParams) && specParams[i] != name {
+			c.report(SeverityWarning, "path parameter {%s} is {%s} in the spec", name, specParams[i])
+		}
+	}
+
+	var inputFields []goField
+	inputName := ""
+	if a.Input != nil {
+		inputName = typeString(a.Input)
+		if st, ok := c.pkg.Underlying(a.Input).(*ast.StructType); ok {
+			inputFields = c.pkg.Fields(st)
+		}
+	}
+
+	// Fields bound to a parameter are not
+	// compared with the request body.
+	bound := map[string]bool{}
+	for _, param := range op.ParametersIn("query") {
+		field, ok := matchField(inputFields, param.Name)
+		if ok {
+			bound[field.Name] = true
+			continue
+		}
+		severity := SeverityWarning
+		if param.Required {
+			severity = SeverityError
+		}
+		c.report(severity, "query parameter %s is not a field of %s", param.Name, cmp.Or(inputName, "the input"))
+	}
+	for _, param := range op.ParametersIn("path") {
+		if field, ok := matchField(inputFields, param.Name); ok {
+			bound[field.Name] = true
+		}
+	}
+
+	requestSchema := op.RequestSchema()
+	if requestSchema != nil && a.Input == nil && op.RequestBody.Required {
+		c.report(SeverityError, "the request body is required but the function has no input")
+	}
+	properties := c.spec.Properties(requestSchema)
+
+	// The remaining fields are either request body fields
+	// or bound to the path parameters, whose names often
+	// differ such as Id for {dnOrId}.
+	pathSlots := len(op.ParametersIn("path"))
+	for _, field := range inputFields {
+		if bound[field.Name] {
+			pathSlots--
+			continue
+		}
+		if _, ok := matchProperty(properties, field.Name); ok {
+			continue
+		}
+		if pathSlots > 0 {
+			pathSlots--
+			continue
+		}
+		c.report(SeverityWarning, "%s field %s is not a parameter or request field in the spec", inputName, field.Name)
+	}
+
+	if requestSchema != nil && len(properties) > 0 && a.Input != nil {
+		for _, name := range sortedKeys(properties) {
+			if _, ok := matchField(inputFields, name); !ok {
+				c.report(SeverityWarning, "request field %s is not in %s", name, inputName)
+			}
+		}
+		for _, field := range inputFields {
+			if property, ok := matchProperty(properties, field.Name); ok {
+				c.compare(field.Type, property, field.Name, "request", inputName, 0)
+			}
+		}
+	}
+
+	if responseSchema := op.ResponseSchema(); responseSchema != nil && a.Output != nil {
+		c.compare(a.Output, responseSchema, "", "response", typeString(a.Output), 0)
+	}
+}
+
+// Compares a Go type with a schema recursively,
+// reporting the fields that only one of them has.
+func (c *checker) compare(goType ast.Expr, schema *Schema, path string, side string, typeName string, depth int) {
+	if depth > 12 {
+		return
+	}
+	schema = c.spec.Resolve(schema)
+	if schema == nil {
+		return
+	}
+	switch t := c.pkg.Underlying(goType).(type) {
+	case *ast.StructType:
+		properties := c.spec.Properties(schema)
+		if len(properties) == 0 {
+			return
+		}
+		fields := c.pkg.Fields(t)
+		for _, name := range sortedKeys(properties) {
+			if _, ok := matchField(fields, name); !ok {
+				c.report(SeverityWarning, "%s field %s is not in %s", side, joinPath(path, name), typeName)
+			}
+		}
+		for _, field := range fields {
+			property, ok := matchProperty(properties, field.Name)
+			if !ok {
+				c.report(SeverityWarning, "%s field %s is not in the %s schema", typeName, joinPath(path, field.Name), side)
+				continue
+			}
+			c.compare(field.Type, property, joinPath(path, field.Name), side, typeName, depth+1)
+		}
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return
+		}
+		if c.spec.TypeOf(schema) == "array" {
+			c.compare(t.Elt, schema.Items, path+"[]", side, typeName, depth+1)
+		}
+	case *ast.MapType:
+		if values := c.spec.AdditionalProperties(schema); values != nil {
+			c.compare(t.Value, values, joinPath(path, "*"), side, typeName, depth+1)
+		}
+	}
+}
+
+// Finds the field of a JSON name, preferring an exact
+// match and falling back to the case-insensitive match
+// of encoding/json.
+func matchField(fields []goField, name string) (goField, bool) {
+	for _, field := range fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+	return goField{}, false
+}
+
+func matchProperty(properties map[string]*Schema, name string) (*Schema, bool) {
+	if property, ok := properties[name]; ok {
+		return property, true
+	}
+	for key, property := range properties {
+		if strings.EqualFold(key, name) {
+			return property, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
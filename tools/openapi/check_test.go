@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func load(t *testing.T, dir string) (*Package, *Spec) {
+	t.Helper()
+	spec, err := LoadSpec("testdata/openapi.json")
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	pkg, err := LoadPackage(dir)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	return pkg, spec
+}
+
+func TestLoadSpec(t *testing.T) {
+	t.Parallel()
+	_, spec := load(t, "testdata/client")
+
+	op := spec.Operation("GET", "/admin/roles/{id}/members")
+	if op == nil {
+		t.Fatal("got no operation for GET /admin/roles/{id}/members")
+	}
+	if got, want := op.OperationId, "getRoleMembers"; got != want {
+		t.Errorf("got %s. want %s", got, want)
+	}
+	if params := op.ParametersIn("path"); len(params) != 1 || params[0].Name != "roleId" {
+		t.Errorf("got path parameters %+v. want roleId", params)
+	}
+
+	// The path item parameters are merged into the operations.
+	if params := spec.Operation("DELETE", "/admin/roles/{roleId}").ParametersIn("path"); len(params) != 1 {
+		t.Errorf("got path parameters %+v. want roleId", params)
+	}
+	if got, want := strings.Join(spec.Methods("/admin/roles/{x}"), ","), "DELETE,GET"; got != want {
+		t.Errorf("got %s. want %s", got, want)
+	}
+
+	request := spec.Operation("POST", "/admin/roles").RequestSchema()
+	if got, want := strings.Join(sortedKeys(spec.Properties(request)), ","), "attributes,id,name,owners"; got != want {
+		t.Errorf("got %s. want %s", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	report := Check(load(t, "testdata/client"))
+
+	var findings []string
+	for _, finding := range report.Findings {
+		findings = append(findings, finding.String())
+	}
+	for _, want := range []string{
+		"Roles.go:28: error: GetRoles (GET /admin/roles): query parameter filter is not a field of GetRolesInput",
+		"Roles.go:28: warning: GetRoles (GET /admin/roles): response field roles[].owners is not in *GetRolesOutput",
+		"Roles.go:28: warning: GetRoles (GET /admin/roles): *GetRolesOutput field roles[].owner is not in the response schema",
+		"Roles.go:48: warning: GetRole (GET /admin/roles/{id}): path parameter {id} is {roleId} in the spec",
+		"Roles.go:64: error: PutRole (PUT /admin/roles/{id}): method PUT is not in the spec, the path has DELETE, GET",
+		"Roles.go:71: error: GetGroups (GET /admin/groups): path /admin/groups is not in the spec",
+	} {
+		if !slices.Contains(findings, want) {
+			t.Errorf("got findings %q. want %q", findings, want)
+		}
+	}
+	for _, finding := range findings {
+		// Map values and the bound path parameter
+		// are not reported.
+		if strings.Contains(finding, "attributes") || strings.Contains(finding, "field Id") {
+			t.Errorf("got finding %q. want none", finding)
+		}
+	}
+
+	var unwrapped []string
+	for _, op := range report.Unwrapped {
+		unwrapped = append(unwrapped, op.Method+" "+op.Path)
+	}
+	if got, want := strings.Join(unwrapped, ","), "POST /admin/roles,DELETE /admin/roles/{roleId},GET /admin/roles/{roleId}/members"; got != want {
+		t.Errorf("got %s. want %s", got, want)
+	}
+
+	if !report.Failed(false) {
+		t.Error("got a passing report. want it failed")
+	}
+	var out bytes.Buffer
+	report.Write(&out)
+	if !strings.Contains(out.String(), "3 unwrapped operations:\n\tPOST /admin/roles (createRole)\n") {
+		t.Errorf("got %s. want the unwrapped operations", out.String())
+	}
+}
+
+func TestReportFailed(t *testing.T) {
+	t.Parallel()
+	report := &Report{Findings: []Finding{{Severity: SeverityWarning}}}
+	if report.Failed(false) {
+		t.Error("got a failed report for a warning. want it passing")
+	}
+	if !report.Failed(true) {
+		t.Error("got a passing report for a strict warning. want it failed")
+	}
+}
+
+func TestRunCheckWithoutSpec(t *testing.T) {
+	t.Setenv(EnvSpec, "")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"check", "testdata/client"}, &stdout, &stderr); code != 0 {
+		t.Errorf("got exit code %d. want 0", code)
+	}
+	if !strings.Contains(stderr.String(), "skipping the check") {
+		t.Errorf("got %s. want a skip notice", stderr.String())
+	}
+}
@@ -0,0 +1,148 @@
+// Openapi checks the //meta:operation annotated methods of a
+// package against a local copy of the RapidIdentity OpenAPI
+// spec and scaffolds methods for operations not yet wrapped.
+//
+// # Check
+//
+// Check reports annotations whose path or method is not in
+// the spec, query parameters missing from the input type,
+// and input and output fields that differ from the request
+// and response schemas, followed by the operations of the
+// spec without an annotation. Errors fail the check, and
+// with -strict so do warnings.
+//
+//	go run ./tools/openapi check -spec openapi.json ./pkg/rapididentity
+//
+// The spec defaults to $RI_OPENAPI_SPEC. The check is skipped
+// when neither is set, since the spec is not distributed with
+// the repository. YAML specs must be converted to JSON first.
+//
+// # Scaffold
+//
+// Scaffold writes the Input and Output types and the method of
+// an operation to <Name>.go, its httptest test to <Name>_test.go
+// and adds the Operation to Operation.go. Fields are documented
+// with the spec description, or a placeholder to rewrite.
+//
+//	go run ./tools/openapi scaffold -spec openapi.json -op "GET /admin/roles/{id}" -name GetRoleById ./pkg/rapididentity
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// The environment variable of the default spec path.
+const EnvSpec = "RI_OPENAPI_SPEC"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: openapi check|scaffold [flags] [dir]")
+		return 2
+	}
+	switch args[0] {
+	case "check":
+		return runCheck(args[1:], stdout, stderr)
+	case "scaffold":
+		return runScaffold(args[1:], stdout, stderr)
+	}
+	fmt.Fprintf(stderr, "openapi: unknown command %q\n", args[0])
+	return 2
+}
+
+func runCheck(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	specPath := flags.String("spec", os.Getenv(EnvSpec), "the OpenAPI spec in JSON")
+	strict := flags.Bool("strict", false, "fail on warnings")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *specPath == "" {
+		fmt.Fprintf(stderr, "openapi: no spec, set -spec or %s; skipping the check\n", EnvSpec)
+		return 0
+	}
+
+	spec, err := LoadSpec(*specPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi: %s\n", err)
+		return 1
+	}
+	pkg, err := LoadPackage(dirArg(flags))
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi: %s\n", err)
+		return 1
+	}
+
+	report := Check(pkg, spec)
+	report.Write(stdout)
+	if report.Failed(*strict) {
+		return 1
+	}
+	return 0
+}
+
+func runScaffold(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("scaffold", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	specPath := flags.String("spec", os.Getenv(EnvSpec), "the OpenAPI spec in JSON")
+	operation := flags.String("op", "", `the operation, for example "GET /admin/roles/{id}"`)
+	name := flags.String("name", "", "the method name, for example GetRoleById")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *specPath == "" || *operation == "" || *name == "" {
+		fmt.Fprintln(stderr, "openapi: scaffold requires -spec, -op and -name")
+		return 2
+	}
+
+	spec, err := LoadSpec(*specPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi: %s\n", err)
+		return 1
+	}
+	var method, path string
+	if _, err := fmt.Sscan(*operation, &method, &path); err != nil {
+		fmt.Fprintf(stderr, "openapi: invalid operation %q\n", *operation)
+		return 2
+	}
+	op := spec.Operation(method, path)
+	if op == nil {
+		fmt.Fprintf(stderr, "openapi: %s is not in the spec\n", *operation)
+		return 1
+	}
+	pkg, err := LoadPackage(dirArg(flags))
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi: %s\n", err)
+		return 1
+	}
+
+	scaffold, err := Generate(pkg, spec, op, *name)
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi: %s\n", err)
+		return 1
+	}
+	written, err := scaffold.Write(pkg, *name)
+	for _, file := range written {
+		fmt.Fprintln(stdout, file)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi: %s\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "openapi: remember to add %s to the interfaces of Api.go, rapididentitytest.Mock and Server, and an example\n", *name)
+	return 0
+}
+
+func dirArg(flags *flag.FlagSet) string {
+	if flags.NArg() > 0 {
+		return flags.Arg(0)
+	}
+	return "."
+}
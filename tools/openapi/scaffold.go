@@ -0,0 +1,556 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// The generated files of an operation.
+type Scaffold struct {
+	// The Input and Output types, the method and
+	// any types of the schemas it references.
+	Source []byte
+
+	// The httptest test of the method.
+	Test []byte
+
+	// The Operation declaration to add
+	// to the operations of Operation.go.
+	Operation string
+}
+
+// Generates the Input and Output types, the method
+// and the test of a spec operation for the package,
+// reusing the types the package already declares.
+func Generate(pkg *Package, spec *Spec, op *SpecOperation, name string) (*Scaffold, error) {
+	g := &generator{
+		pkg:       pkg,
+		spec:      spec,
+		op:        op,
+		name:      name,
+		generated: map[string]bool{},
+		imports:   map[string]bool{"context": true},
+	}
+	return g.generate()
+}
+
+type generator struct {
+	pkg  *Package
+	spec *Spec
+	op   *SpecOperation
+	name string
+
+	// The struct types to declare, in order.
+	types     bytes.Buffer
+	generated map[string]bool
+	imports   map[string]bool
+}
+
+// A field of a generated struct.
+type genField struct {
+	Name        string
+	JSONName    string
+	Type        string
+	Description string
+	In          string
+}
+
+func (g *generator) generate() (*Scaffold, error) {
+	if _, ok := g.pkg.Types[g.name+"Input"]; ok {
+		return nil, fmt.Errorf("%sInput is already declared in %s", g.name, g.pkg.Dir)
+	}
+
+	inputName := g.name + "Input"
+	var inputFields []genField
+	for _, in := range []string{"path", "query"} {
+		for _, param := range g.op.ParametersIn(in) {
+			inputFields = append(inputFields, genField{
+				Name:        exportName(param.Name),
+				JSONName:    param.Name,
+				Type:        g.goType(param.Schema, g.name+exportName(param.Name)),
+				Description: describe(param.Description, param.Name, in),
+				In:          in,
+			})
+		}
+	}
+	bodyField := ""
+	if body := g.op.RequestSchema(); body != nil {
+		if g.spec.TypeOf(body) == "object" && len(g.spec.Properties(body)) > 0 {
+			inputFields = append(inputFields, g.fields(body, g.name+"Request")...)
+		} else {
+			bodyField = "Body"
+			inputFields = append(inputFields, genField{
+				Name:        bodyField,
+				JSONName:    "body",
+				Type:        g.goType(body, g.name+"Request"),
+				Description: "The request body.",
+			})
+		}
+	}
+	hasInput := len(inputFields) > 0
+	if hasInput {
+		g.writeStruct(inputName, fmt.Sprintf("Params for %s method.", g.name), inputFields)
+	}
+
+	// The output is returned as a pointer unless
+	// it is a slice or map.
+	outputType := ""
+	pointer := true
+	if response := g.op.ResponseSchema(); response != nil {
+		if g.spec.TypeOf(response) == "object" && response.Ref == "" && len(g.spec.Properties(response)) > 0 {
+			outputType = g.name + "Output"
+			g.writeStruct(outputType, fmt.Sprintf("Output for the %s method.", g.name), g.fields(response, outputType))
+		} else {
+			outputType = g.goType(response, g.name+"Output")
+			pointer = !strings.HasPrefix(outputType, "[]") && !strings.HasPrefix(outputType, "map[")
+			if underlying, ok := g.pkg.Underlying(ast.NewIdent(outputType)).(*ast.ArrayType); ok && underlying != nil {
+				pointer = false
+			}
+		}
+	}
+
+	var src bytes.Buffer
+	g.writeMethod(&src, inputName, hasInput, inputFields, bodyField, outputType, pointer)
+
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "package %s\n\nimport (\n", g.pkg.Name)
+	for _, path := range sortedKeys(g.imports) {
+		fmt.Fprintf(&file, "\t%q\n", path)
+	}
+	file.WriteString(")\n\n")
+	file.Write(g.types.Bytes())
+	file.Write(src.Bytes())
+	source, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting the generated source: %w\n%s", err, file.Bytes())
+	}
+
+	test, err := format.Source(g.test(inputName, hasInput, inputFields, outputType))
+	if err != nil {
+		return nil, fmt.Errorf("formatting the generated test: %w", err)
+	}
+
+	idempotent, mutating := methodSemantics(g.op.Method)
+	operation := fmt.Sprintf("op%s = Operation{Name: %q, Method: %q, Path: %q", g.name, g.name, g.op.Method, g.op.Path)
+	if idempotent {
+		operation += ", Idempotent: true"
+	}
+	if mutating {
+		operation += ", Mutating: true"
+	}
+	operation += "}"
+
+	return &Scaffold{Source: source, Test: test, Operation: operation}, nil
+}
+
+// Returns the fields of an object schema, generating
+// the types of nested objects named after the parent.
+func (g *generator) fields(schema *Schema, parent string) []genField {
+	properties := g.spec.Properties(schema)
+	var fields []genField
+	for _, name := range sortedKeys(properties) {
+		property := properties[name]
+		description := property.Description
+		if resolved := g.spec.Resolve(property); description == "" && resolved != nil {
+			description = resolved.Description
+		}
+		fields = append(fields, genField{
+			Name:        exportName(name),
+			JSONName:    name,
+			Type:        g.goType(property, parent+exportName(name)),
+			Description: describe(description, name, ""),
+		})
+	}
+	return fields
+}
+
+// Returns the description of a field, or a sentence
+// naming the field when the spec has none, so every
+// generated field has a doc comment and jsonschema tag.
+func describe(description string, jsonName string, in string) string {
+	if oneLine(description) != "" {
+		return description
+	}
+	words := humanize(jsonName)
+	if in != "" {
+		return fmt.Sprintf("The %s %s parameter.", words, in)
+	}
+	return fmt.Sprintf("The %s field.", words)
+}
+
+// Returns the Go type of a schema. Referenced schemas are
+// named after the reference and reused when the package
+// declares them; inline objects are named by the hint.
+func (g *generator) goType(schema *Schema, hint string) string {
+	if schema == nil {
+		return "any"
+	}
+	if schema.Ref != "" {
+		name := exportName(refName(schema.Ref))
+		if _, ok := g.pkg.Types[name]; ok || g.generated[name] {
+			return name
+		}
+		resolved := g.spec.Resolve(schema)
+		if g.spec.TypeOf(resolved) != "object" || len(g.spec.Properties(resolved)) == 0 {
+			return g.goType(&Schema{Type: resolved.Type, Format: resolved.Format, Items: resolved.Items, AdditionalProperties: resolved.AdditionalProperties}, name)
+		}
+		g.generated[name] = true
+		g.writeStruct(name, resolved.Description, g.fields(resolved, name))
+		return name
+	}
+
+	switch g.spec.TypeOf(schema) {
+	case "string":
+		if schema.Format == "date-time" {
+			g.imports["time"] = true
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		if schema.Format == "int64" {
+			return "int64"
+		}
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		item := g.goType(schema.Items, hint+"Item")
+		if _, ok := g.pkg.Types["StringList"]; ok && item == "string" {
+			return "StringList"
+		}
+		return "[]" + item
+	case "object":
+		if len(g.spec.Properties(schema)) > 0 {
+			name := hint
+			for g.generated[name] || g.pkg.Types[name] != nil {
+				name += "_"
+			}
+			g.generated[name] = true
+			g.writeStruct(name, schema.Description, g.fields(schema, name))
+			return name
+		}
+		if values := g.spec.AdditionalProperties(schema); values != nil {
+			return "map[string]" + g.goType(values, hint+"Value")
+		}
+		return "map[string]any"
+	}
+	return "any"
+}
+
+func (g *generator) writeStruct(name string, description string, fields []genField) {
+	writeComment(&g.types, "", description)
+	fmt.Fprintf(&g.types, "type %s struct {\n", name)
+	for i, field := range fields {
+		if i > 0 {
+			g.types.WriteString("\n")
+		}
+		writeComment(&g.types, "\t", field.Description)
+		tag := fmt.Sprintf("json:%q jsonschema:%q", field.JSONName, oneLine(field.Description))
+		fmt.Fprintf(&g.types, "\t%s %s `%s`\n", field.Name, field.Type, strings.ReplaceAll(tag, "`", "'"))
+	}
+	g.types.WriteString("}\n\n")
+}
+
+func (g *generator) writeMethod(w *bytes.Buffer, inputName string, hasInput bool, fields []genField, bodyField string, outputType string, pointer bool) {
+	op := g.op
+	writeComment(w, "", cmp.Or(op.Summary, op.Description, fmt.Sprintf("Calls %s %s.", op.Method, op.Path)))
+	fmt.Fprintf(w, "//\n//meta:operation %s %s\n", op.Method, op.Path)
+
+	params := ""
+	if hasInput {
+		params = ", params " + inputName
+	}
+	switch {
+	case outputType == "":
+		fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context%s) error {\n", g.name, params)
+	case pointer:
+		fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context%s) (*%s, error) {\n", g.name, params, outputType)
+	default:
+		fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context%s) (%s, error) {\n", g.name, params, outputType)
+	}
+	fail := "return nil, err"
+	if outputType == "" {
+		fail = "return err"
+	} else {
+		fmt.Fprintf(w, "\tvar output %s\n\n", outputType)
+	}
+
+	path := fmt.Sprintf("%q", op.Path)
+	for _, field := range fields {
+		if field.In != "path" {
+			continue
+		}
+		value := "params." + field.Name
+		if field.Type != "string" {
+			g.imports["fmt"] = true
+			value = "fmt.Sprint(" + value + ")"
+		}
+		g.imports["net/url"] = true
+		path = strings.Replace(path, "{"+field.JSONName+"}", `"+url.PathEscape(`+value+`)+"`, 1)
+	}
+	path = strings.TrimSuffix(strings.ReplaceAll(path, `+""`, ""), `+""`)
+
+	query := "nil"
+	if slices.ContainsFunc(fields, func(f genField) bool { return f.In == "query" }) {
+		query = "query"
+		g.imports["net/url"] = true
+		w.WriteString("\tquery := url.Values{}\n")
+		for _, field := range fields {
+			if field.In != "query" {
+				continue
+			}
+			writeQueryParam(w, g, field)
+		}
+	}
+	fmt.Fprintf(w, "\tendpointUrl := c.endpoint(%s, %s)\n", path, query)
+
+	body := "nil"
+	if g.op.RequestSchema() != nil {
+		g.imports["bytes"] = true
+		g.imports["encoding/json"] = true
+		value := "params"
+		if bodyField != "" {
+			value = "params." + bodyField
+		}
+		fmt.Fprintf(w, "\tbody, err := json.Marshal(%s)\n\tif err != nil {\n\t\t%s\n\t}\n", value, fail)
+		w.WriteString("\trequestBody := bytes.NewBuffer(body)\n")
+		body = "requestBody"
+	}
+	fmt.Fprintf(w, "\treq, err := c.GenerateRequest(ctx, %q, endpointUrl, %s)\n\tif err != nil {\n\t\t%s\n\t}\n", op.Method, body, fail)
+	if body != "nil" {
+		w.WriteString("\treq.Header.Add(\"Content-Type\", \"application/json\")\n")
+	}
+	w.WriteString("\n")
+
+	input := "nil"
+	if hasInput {
+		input = "params"
+	}
+	if outputType == "" {
+		w.WriteString("\tvar resBody []byte\n")
+		fmt.Fprintf(w, "\terr = c.invoke(ctx, op%s, %s, req, &resBody)\n\tif err != nil {\n\t\treturn err\n\t}\n\n\treturn nil\n}\n", g.name, input)
+		return
+	}
+	fmt.Fprintf(w, "\terr = c.invoke(ctx, op%s, %s, req, &output)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n", g.name, input)
+	if pointer {
+		w.WriteString("\treturn &output, nil\n}\n")
+	} else {
+		w.WriteString("\treturn output, nil\n}\n")
+	}
+}
+
+func writeQueryParam(w *bytes.Buffer, g *generator, field genField) {
+	value := "params." + field.Name
+	switch field.Type {
+	case "string":
+		fmt.Fprintf(w, "\tif %s != \"\" {\n\t\tquery.Set(%q, %s)\n\t}\n", value, field.JSONName, value)
+	case "int":
+		g.imports["strconv"] = true
+		fmt.Fprintf(w, "\tif %s != 0 {\n\t\tquery.Set(%q, strconv.Itoa(%s))\n\t}\n", value, field.JSONName, value)
+	case "bool":
+		fmt.Fprintf(w, "\tif %s {\n\t\tquery.Set(%q, \"true\")\n\t}\n", value, field.JSONName)
+	case "StringList", "[]string":
+		fmt.Fprintf(w, "\tfor _, value := range %s {\n\t\tquery.Add(%q, value)\n\t}\n", value, field.JSONName)
+	default:
+		g.imports["fmt"] = true
+		fmt.Fprintf(w, "\tquery.Set(%q, fmt.Sprint(%s))\n", field.JSONName, value)
+	}
+}
+
+// Returns the source of the httptest test of the method.
+func (g *generator) test(inputName string, hasInput bool, fields []genField, outputType string) []byte {
+	var w bytes.Buffer
+	fmt.Fprintf(&w, "package %s\n\nimport (\n\t\"context\"\n\t\"fmt\"\n\t\"net/http\"\n\t\"testing\"\n)\n\n", g.pkg.Name)
+	fmt.Fprintf(&w, "func Test%s(t *testing.T) {\n\tt.Parallel()\n\tclient, mux := setup(t)\n", g.name)
+
+	path := g.op.Path
+	values := map[string]string{}
+	for _, field := range fields {
+		if field.In == "path" {
+			value := "test-" + strings.ToLower(field.JSONName)
+			if field.Type != "string" {
+				value = "1"
+			}
+			values[field.Name] = value
+			path = strings.Replace(path, "{"+field.JSONName+"}", value, 1)
+		}
+	}
+	fmt.Fprintf(&w, "\tmux.HandleFunc(baseUrlPath+%q, func(w http.ResponseWriter, r *http.Request) {\n", path)
+	fmt.Fprintf(&w, "\t\ttestMethod(t, r, %q)\n", g.op.Method)
+	w.WriteString("\t\ttestHeader(t, r, \"Authorization\", \"Bearer \"+mockServiceIdentity)\n\n")
+	w.WriteString("\t\tw.WriteHeader(http.StatusOK)\n")
+	response := "{}"
+	if outputType == "" {
+		response = ""
+	} else if underlying, ok := g.pkg.Underlying(ast.NewIdent(outputType)).(*ast.ArrayType); strings.HasPrefix(outputType, "[]") || (ok && underlying != nil) {
+		response = "[]"
+	}
+	fmt.Fprintf(&w, "\t\tfmt.Fprint(w, `%s`)\n\t})\n\n", response)
+
+	args := "ctx"
+	if hasInput {
+		fmt.Fprintf(&w, "\tinput := %s{\n", inputName)
+		for _, field := range fields {
+			if value, ok := values[field.Name]; ok {
+				if field.Type == "string" {
+					fmt.Fprintf(&w, "\t\t%s: %q,\n", field.Name, value)
+				} else {
+					fmt.Fprintf(&w, "\t\t%s: %s,\n", field.Name, value)
+				}
+			}
+		}
+		w.WriteString("\t}\n")
+		args = "ctx, input"
+	}
+	w.WriteString("\tctx := context.Background()\n")
+	if outputType == "" {
+		fmt.Fprintf(&w, "\terr := client.%s(%s)\n", g.name, args)
+	} else {
+		fmt.Fprintf(&w, "\t_, err := client.%s(%s)\n", g.name, args)
+	}
+	w.WriteString("\tif err != nil {\n\t\tt.Errorf(\"got error %s, want none\", err)\n\t}\n}\n")
+	return w.Bytes()
+}
+
+// Writes the scaffold into the package directory and
+// adds the operation to Operation.go. Existing files
+// are not overwritten.
+func (s *Scaffold) Write(pkg *Package, name string) ([]string, error) {
+	files := map[string][]byte{
+		name + ".go":      s.Source,
+		name + "_test.go": s.Test,
+	}
+	for file := range files {
+		if _, err := os.Stat(filepath.Join(pkg.Dir, file)); err == nil {
+			return nil, fmt.Errorf("%s already exists", filepath.Join(pkg.Dir, file))
+		}
+	}
+
+	var written []string
+	if err := s.addOperation(pkg); err != nil {
+		return nil, err
+	}
+	written = append(written, filepath.Join(pkg.Dir, "Operation.go"))
+	for _, file := range sortedKeys(files) {
+		path := filepath.Join(pkg.Dir, file)
+		if err := os.WriteFile(path, files[file], 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// Appends the operation to the var block of
+// Operation.go that declares the operations.
+func (s *Scaffold) addOperation(pkg *Package) error {
+	file, ok := pkg.Files["Operation.go"]
+	if !ok {
+		return fmt.Errorf("%s has no Operation.go", pkg.Dir)
+	}
+	path := filepath.Join(pkg.Dir, "Operation.go")
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || !gen.Rparen.IsValid() || len(gen.Specs) == 0 {
+			continue
+		}
+		spec, ok := gen.Specs[0].(*ast.ValueSpec)
+		if !ok || !strings.HasPrefix(spec.Names[0].Name, "op") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		offset := pkg.Fset.Position(gen.Rparen).Offset
+		updated := slices.Concat(src[:offset], []byte("\t"+s.Operation+"\n"), src[offset:])
+		formatted, err := format.Source(updated)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, formatted, 0o644)
+	}
+	return fmt.Errorf("%s declares no operations", path)
+}
+
+// Whether an HTTP method is idempotent and mutating,
+// following customOperation of the package.
+func methodSemantics(method string) (bool, bool) {
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return true, false
+	case "PUT", "DELETE":
+		return true, true
+	}
+	return false, true
+}
+
+// Returns the exported Go name of a JSON name,
+// for example userId becomes UserId.
+func exportName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	exported := b.String()
+	if exported == "" || unicode.IsDigit(rune(exported[0])) {
+		exported = "X" + exported
+	}
+	return exported
+}
+
+// Returns the lower case words of a JSON name,
+// for example roleId becomes role id.
+func humanize(name string) string {
+	var b strings.Builder
+	previous := rune(0)
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			r = ' '
+		} else if unicode.IsUpper(r) && unicode.IsLower(previous) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+		previous = r
+	}
+	return strings.ToLower(strings.Join(strings.Fields(b.String()), " "))
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Writes the text as a comment wrapped at 60 columns.
+func writeComment(w *bytes.Buffer, indent string, text string) {
+	words := strings.Fields(text)
+	line := ""
+	for _, word := range words {
+		if line != "" && len(line)+1+len(word) > 60 {
+			fmt.Fprintf(w, "%s// %s\n", indent, line)
+			line = ""
+		}
+		line = strings.TrimSpace(line + " " + word)
+	}
+	if line != "" {
+		fmt.Fprintf(w, "%s// %s\n", indent, line)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata/golden")
+
+// Copies the test package into a temporary directory.
+func copyPackage(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	entries, err := os.ReadDir("testdata/client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join("testdata/client", entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, entry.Name()), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func scaffold(t *testing.T, dir string, method string, path string, name string) {
+	t.Helper()
+	pkg, spec := load(t, dir)
+	scaffold, err := Generate(pkg, spec, spec.Operation(method, path), name)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if _, err := scaffold.Write(pkg, name); err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	return string(data)
+}
+
+func TestScaffold(t *testing.T) {
+	t.Parallel()
+	dir := copyPackage(t)
+	scaffold(t, dir, "GET", "/admin/roles/{roleId}/members", "GetRoleMembers")
+	scaffold(t, dir, "POST", "/admin/roles", "CreateRole")
+	scaffold(t, dir, "DELETE", "/admin/roles/{roleId}", "DeleteRole")
+
+	source := readFile(t, filepath.Join(dir, "GetRoleMembers.go"))
+	for _, want := range []string{
+		"\t// The role id path parameter.\n\tRoleId string `json:\"roleId\" jsonschema:\"The role id path parameter.\"`",
+		"// A member of a role.\ntype Member struct {",
+		"\t// When the members last changed.\n\tUpdated time.Time `json:\"updated\" jsonschema:\"When the members last changed.\"`",
+		"//meta:operation GET /admin/roles/{roleId}/members\nfunc (c *Client) GetRoleMembers(ctx context.Context, params GetRoleMembersInput) (*GetRoleMembersOutput, error) {",
+		"\t\tquery.Add(\"attrs\", value)",
+		"c.endpoint(\"/admin/roles/\"+url.PathEscape(params.RoleId)+\"/members\", query)",
+		"c.invoke(ctx, opGetRoleMembers, params, req, &output)",
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("got %s. want it to contain %s", source, want)
+		}
+	}
+
+	// The request body reuses the Role type
+	// the package already declares.
+	create := readFile(t, filepath.Join(dir, "CreateRole.go"))
+	for _, want := range []string{
+		"(*Role, error)",
+		"body, err := json.Marshal(params)",
+		"req.Header.Add(\"Content-Type\", \"application/json\")",
+	} {
+		if !strings.Contains(create, want) {
+			t.Errorf("got %s. want it to contain %s", create, want)
+		}
+	}
+	if strings.Contains(create, "type Role struct") {
+		t.Errorf("got %s. want Role reused", create)
+	}
+
+	remove := readFile(t, filepath.Join(dir, "DeleteRole.go"))
+	if !strings.Contains(remove, "params DeleteRoleInput) error {") || !strings.Contains(remove, "var resBody []byte") {
+		t.Errorf("got %s. want an error-only method", remove)
+	}
+
+	test := readFile(t, filepath.Join(dir, "GetRoleMembers_test.go"))
+	for _, want := range []string{
+		"func TestGetRoleMembers(t *testing.T) {\n\tt.Parallel()\n\tclient, mux := setup(t)",
+		"mux.HandleFunc(baseUrlPath+\"/admin/roles/test-roleid/members\"",
+		"testMethod(t, r, \"GET\")",
+		"RoleId: \"test-roleid\",",
+	} {
+		if !strings.Contains(test, want) {
+			t.Errorf("got %s. want it to contain %s", test, want)
+		}
+	}
+
+	operations := readFile(t, filepath.Join(dir, "Operation.go"))
+	for _, want := range []string{
+		`opGetRoleMembers = Operation{Name: "GetRoleMembers", Method: "GET", Path: "/admin/roles/{roleId}/members", Idempotent: true}`,
+		`opCreateRole     = Operation{Name: "CreateRole", Method: "POST", Path: "/admin/roles", Mutating: true}`,
+		`opDeleteRole     = Operation{Name: "DeleteRole", Method: "DELETE", Path: "/admin/roles/{roleId}", Idempotent: true, Mutating: true}`,
+	} {
+		if !strings.Contains(operations, want) {
+			t.Errorf("got %s. want it to contain %s", operations, want)
+		}
+	}
+
+	// The scaffolded methods conform to the spec, apart from
+	// the Role type of the test package.
+	report := Check(load(t, dir))
+	for _, finding := range report.Findings {
+		if slices.Contains([]string{"GetRoleMembers", "CreateRole", "DeleteRole"}, finding.Annotation.Func) && !strings.Contains(finding.Message, "owner") {
+			t.Errorf("got finding %s. want none", finding)
+		}
+	}
+	if len(report.Unwrapped) != 0 {
+		t.Errorf("got unwrapped operations %v. want none", report.Unwrapped)
+	}
+}
+
+// Compares the generated files with testdata/golden.
+// Run go test -update after intended changes.
+func TestScaffoldGolden(t *testing.T) {
+	t.Parallel()
+	pkg, spec := load(t, "testdata/client")
+	for _, tc := range []struct {
+		method, path, name string
+	}{
+		{"GET", "/admin/roles/{roleId}/members", "GetRoleMembers"},
+		{"POST", "/admin/roles", "CreateRole"},
+		{"DELETE", "/admin/roles/{roleId}", "DeleteRole"},
+	} {
+		scaffold, err := Generate(pkg, spec, spec.Operation(tc.method, tc.path), tc.name)
+		if err != nil {
+			t.Fatalf("%s: got error %s, want none", tc.name, err)
+		}
+		for file, got := range map[string][]byte{
+			tc.name + ".go.golden":      scaffold.Source,
+			tc.name + "_test.go.golden": scaffold.Test,
+		} {
+			golden := filepath.Join("testdata/golden", file)
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			if want := readFile(t, golden); !bytes.Equal(got, []byte(want)) {
+				t.Errorf("%s: got\n%s\nwant\n%s", golden, got, want)
+			}
+		}
+	}
+}
+
+func TestScaffoldExistingFile(t *testing.T) {
+	t.Parallel()
+	dir := copyPackage(t)
+	if err := os.WriteFile(filepath.Join(dir, "DeleteRole.go"), []byte("package client\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkg, spec := load(t, dir)
+	scaffold, err := Generate(pkg, spec, spec.Operation("DELETE", "/admin/roles/{roleId}"), "DeleteRole")
+	if err != nil {
+		t.Fatalf("got error %s, want none", err)
+	}
+	if _, err := scaffold.Write(pkg, "DeleteRole"); err == nil {
+		t.Error("got no error. want the existing file refused")
+	}
+	if got := readFile(t, filepath.Join(dir, "DeleteRole.go")); got != "package client\n" {
+		t.Errorf("got %s. want the file unchanged", got)
+	}
+	if strings.Contains(readFile(t, filepath.Join(dir, "Operation.go")), "opDeleteRole") {
+		t.Error("got opDeleteRole in Operation.go. want it unchanged")
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]string{
+		"roleId":     "role id",
+		"dnOrId":     "dn or id",
+		"x-trace-id": "x trace id",
+		"ldapDN":     "ldap dn",
+	} {
+		if got := humanize(name); got != want {
+			t.Errorf("got %s. want %s", got, want)
+		}
+	}
+}
+
+func TestExportName(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]string{
+		"roleId":     "RoleId",
+		"dnOrId":     "DnOrId",
+		"x-trace-id": "XTraceId",
+		"2fa":        "X2fa",
+	} {
+		if got := exportName(name); got != want {
+			t.Errorf("got %s. want %s", got, want)
+		}
+	}
+}
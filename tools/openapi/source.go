@@ -0,0 +1,246 @@
+package main
+
+import (
+	"cmp"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A //meta:operation annotation of a function.
+type Annotation struct {
+	// The annotated function.
+	Func string
+	Pos  token.Position
+
+	// The HTTP method and the path relative to /api/rest.
+	Method string
+	Path   string
+
+	// The input and output types of the function. Nil
+	// when the function has no input parameter after
+	// the context, or returns only an error.
+	Input  ast.Expr
+	Output ast.Expr
+}
+
+func (a Annotation) Operation() string {
+	return a.Method + " " + a.Path
+}
+
+// The Go source of a package.
+type Package struct {
+	Name        string
+	Dir         string
+	Fset        *token.FileSet
+	Files       map[string]*ast.File
+	Types       map[string]ast.Expr
+	Annotations []Annotation
+
+	// The types with an UnmarshalJSON method,
+	// which decode themselves.
+	unmarshalers map[string]bool
+}
+
+var metaOperation = regexp.MustCompile(`^//meta:operation ([A-Z]+) (/\S*)$`)
+
+// Parses the non-test Go files of the package
+// in dir and collects its annotations.
+func LoadPackage(dir string) (*Package, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Package{
+		Dir:          dir,
+		Fset:         token.NewFileSet(),
+		Files:        map[string]*ast.File{},
+		Types:        map[string]ast.Expr{},
+		unmarshalers: map[string]bool{},
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(pkg.Fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Name = file.Name.Name
+		pkg.Files[name] = file
+		pkg.collect(file)
+	}
+	slices.SortFunc(pkg.Annotations, func(a, b Annotation) int {
+		if c := strings.Compare(a.Pos.Filename, b.Pos.Filename); c != 0 {
+			return c
+		}
+		return a.Pos.Line - b.Pos.Line
+	})
+	return pkg, nil
+}
+
+func (p *Package) collect(file *ast.File) {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok {
+					p.Types[spec.Name.Name] = spec.Type
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv != nil && decl.Name.Name == "UnmarshalJSON" {
+				p.unmarshalers[receiverName(decl.Recv.List[0].Type)] = true
+			}
+			if decl.Doc == nil {
+				continue
+			}
+			for _, comment := range decl.Doc.List {
+				match := metaOperation.FindStringSubmatch(comment.Text)
+				if match == nil {
+					continue
+				}
+				position := p.Fset.Position(decl.Pos())
+				position.Filename = filepath.Base(position.Filename)
+				annotation := Annotation{
+					Func:   decl.Name.Name,
+					Pos:    position,
+					Method: match[1],
+					Path:   match[2],
+				}
+				if params := fieldTypes(decl.Type.Params); len(params) > 1 {
+					annotation.Input = params[1]
+				}
+				if results := fieldTypes(decl.Type.Results); len(results) > 1 {
+					annotation.Output = results[0]
+				}
+				p.Annotations = append(p.Annotations, annotation)
+			}
+		}
+	}
+}
+
+// Returns the type of every parameter or result.
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, field := range fields.List {
+		for range max(len(field.Names), 1) {
+			types = append(types, field.Type)
+		}
+	}
+	return types
+}
+
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// A JSON field of a struct type.
+type goField struct {
+	Name string
+	Type ast.Expr
+}
+
+// Resolves the type expression to the underlying type
+// declared in the package. Pointers are dereferenced.
+// Returns nil for types that decode themselves or are
+// not declared in the package, such as time.Time.
+func (p *Package) Underlying(expr ast.Expr) ast.Expr {
+	for range 32 {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.Ident:
+			if p.unmarshalers[t.Name] {
+				return nil
+			}
+			underlying, ok := p.Types[t.Name]
+			if !ok {
+				return nil
+			}
+			expr = underlying
+		case *ast.StructType, *ast.ArrayType, *ast.MapType:
+			return expr
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// Returns the JSON fields of a struct type following
+// the encoding/json rules: exported fields named by their
+// json tag, skipping "-", with the fields of untagged
+// embedded structs promoted.
+func (p *Package) Fields(st *ast.StructType) []goField {
+	var fields []goField
+	for _, field := range st.Fields.List {
+		tag := ""
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value).Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if len(field.Names) == 0 {
+			if name == "" {
+				if embedded, ok := p.Underlying(field.Type).(*ast.StructType); ok {
+					fields = append(fields, p.Fields(embedded)...)
+					continue
+				}
+			}
+			typeName := receiverName(field.Type)
+			if !ast.IsExported(typeName) {
+				continue
+			}
+			fields = append(fields, goField{Name: cmp.Or(name, typeName), Type: field.Type})
+			continue
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			fields = append(fields, goField{Name: cmp.Or(name, ident.Name), Type: field.Type})
+		}
+	}
+	return fields
+}
+
+// Returns the name of a type expression
+// for messages, for example *GetUserByIdInput.
+func typeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return "*" + typeString(t.X)
+	case *ast.SelectorExpr:
+		return typeString(t.X) + "." + t.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + typeString(t.Elt)
+	case *ast.MapType:
+		return "map[" + typeString(t.Key) + "]" + typeString(t.Value)
+	}
+	return "?"
+}
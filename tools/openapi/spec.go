@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// The HTTP methods of an OpenAPI path item.
+var httpMethods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH"}
+
+// The prefix of the REST API paths, which the
+// //meta:operation paths leave out.
+const apiPrefix = "/api/rest"
+
+// An OpenAPI 3 document in JSON.
+type Spec struct {
+	OpenAPI    string                     `json:"openapi"`
+	Paths      map[string]json.RawMessage `json:"paths"`
+	Components Components                 `json:"components"`
+
+	// The operations keyed by their
+	// normalized operation key.
+	operations map[string]*SpecOperation
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas"`
+	Parameters    map[string]*Parameter   `json:"parameters"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies"`
+	Responses     map[string]*Response    `json:"responses"`
+}
+
+// An operation of the spec with the parameters of
+// its path item merged and references resolved.
+type SpecOperation struct {
+	Method      string               `json:"-"`
+	Path        string               `json:"-"`
+	OperationId string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Ref      string               `json:"$ref"`
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref         string             `json:"$ref"`
+	Type        any                `json:"type"`
+	Format      string             `json:"format"`
+	Description string             `json:"description"`
+	Properties  map[string]*Schema `json:"properties"`
+	Required    []string           `json:"required"`
+	Items       *Schema            `json:"items"`
+	AllOf       []*Schema          `json:"allOf"`
+	OneOf       []*Schema          `json:"oneOf"`
+	AnyOf       []*Schema          `json:"anyOf"`
+
+	// A boolean or a schema.
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+}
+
+// Reads an OpenAPI 3 document in JSON. YAML documents
+// must be converted first, for example with yq -o json.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("%s: %w (the spec must be JSON)", path, err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%s: unsupported OpenAPI version %q, want 3.x", path, spec.OpenAPI)
+	}
+
+	spec.operations = map[string]*SpecOperation{}
+	for path, raw := range spec.Paths {
+		var item map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return nil, fmt.Errorf("paths %s: %w", path, err)
+		}
+		var shared []*Parameter
+		if params, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(params, &shared); err != nil {
+				return nil, fmt.Errorf("paths %s parameters: %w", path, err)
+			}
+		}
+		path = strings.TrimPrefix(path, apiPrefix)
+		for key, raw := range item {
+			method := strings.ToUpper(key)
+			if !slices.Contains(httpMethods, method) {
+				continue
+			}
+			op := &SpecOperation{Method: method, Path: path}
+			if err := json.Unmarshal(raw, op); err != nil {
+				return nil, fmt.Errorf("paths %s %s: %w", path, key, err)
+			}
+			op.Parameters = spec.mergeParameters(shared, op.Parameters)
+			if op.RequestBody != nil && op.RequestBody.Ref != "" {
+				op.RequestBody = spec.Components.RequestBodies[refName(op.RequestBody.Ref)]
+			}
+			for code, response := range op.Responses {
+				if response != nil && response.Ref != "" {
+					op.Responses[code] = spec.Components.Responses[refName(response.Ref)]
+				}
+			}
+			spec.operations[operationKey(method, path)] = op
+		}
+	}
+	return &spec, nil
+}
+
+// Resolves the parameters, the operation parameters
+// overriding the path item parameters of the same
+// name and location.
+func (s *Spec) mergeParameters(shared []*Parameter, own []*Parameter) []*Parameter {
+	var merged []*Parameter
+	for _, param := range slices.Concat(own, shared) {
+		if param.Ref != "" {
+			param = s.Components.Parameters[refName(param.Ref)]
+		}
+		if param == nil {
+			continue
+		}
+		if slices.ContainsFunc(merged, func(p *Parameter) bool { return p.Name == param.Name && p.In == param.In }) {
+			continue
+		}
+		merged = append(merged, param)
+	}
+	return merged
+}
+
+// Returns the operation of the method and path.
+func (s *Spec) Operation(method string, path string) *SpecOperation {
+	return s.operations[operationKey(method, path)]
+}
+
+// Returns the methods of the operations on the path.
+func (s *Spec) Methods(path string) []string {
+	var methods []string
+	for _, op := range s.operations {
+		if normalizePath(op.Path) == normalizePath(path) {
+			methods = append(methods, op.Method)
+		}
+	}
+	slices.Sort(methods)
+	return methods
+}
+
+// Returns the operations sorted by path and method.
+func (s *Spec) Operations() []*SpecOperation {
+	ops := make([]*SpecOperation, 0, len(s.operations))
+	for _, op := range s.operations {
+		ops = append(ops, op)
+	}
+	slices.SortFunc(ops, func(a, b *SpecOperation) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return ops
+}
+
+// Resolves a schema reference, following chains.
+func (s *Spec) Resolve(schema *Schema) *Schema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 32; i++ {
+		schema = s.Components.Schemas[refName(schema.Ref)]
+	}
+	return schema
+}
+
+// Returns the properties of an object schema
+// including those of its allOf schemas.
+func (s *Spec) Properties(schema *Schema) map[string]*Schema {
+	schema = s.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	properties := map[string]*Schema{}
+	for _, part := range schema.AllOf {
+		for name, property := range s.Properties(part) {
+			properties[name] = property
+		}
+	}
+	for name, property := range schema.Properties {
+		properties[name] = property
+	}
+	return properties
+}
+
+// Returns the JSON type of the schema, the first
+// non-null type for OpenAPI 3.1 type lists.
+func (s *Spec) TypeOf(schema *Schema) string {
+	schema = s.Resolve(schema)
+	if schema == nil {
+		return ""
+	}
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []any:
+		for _, value := range t {
+			if name, ok := value.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if len(schema.Properties) > 0 || len(schema.AllOf) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+// Returns the schema of the map values of an object
+// schema with additionalProperties, or nil.
+func (s *Spec) AdditionalProperties(schema *Schema) *Schema {
+	schema = s.Resolve(schema)
+	if schema == nil || len(schema.AdditionalProperties) == 0 {
+		return nil
+	}
+	var values Schema
+	if err := json.Unmarshal(schema.AdditionalProperties, &values); err != nil {
+		return nil
+	}
+	return &values
+}
+
+// Returns the JSON schema of the first successful
+// response of the operation, or nil.
+func (op *SpecOperation) ResponseSchema() *Schema {
+	for _, code := range []string{"200", "201", "202", "203", "206", "2XX", "default"} {
+		if response, ok := op.Responses[code]; ok && response != nil {
+			return jsonSchema(response.Content)
+		}
+	}
+	return nil
+}
+
+// Returns the JSON schema of the request body, or nil.
+func (op *SpecOperation) RequestSchema() *Schema {
+	if op.RequestBody == nil {
+		return nil
+	}
+	return jsonSchema(op.RequestBody.Content)
+}
+
+// Returns the parameters in the location,
+// such as path or query.
+func (op *SpecOperation) ParametersIn(in string) []*Parameter {
+	var params []*Parameter
+	for _, param := range op.Parameters {
+		if param.In == in {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+func jsonSchema(content map[string]MediaType) *Schema {
+	for mediaType, media := range content {
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+// Returns the last segment of a reference
+// such as #/components/schemas/ConnectJob.
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// Replaces the path parameter names with {} so that
+// paths match regardless of the parameter names.
+func normalizePath(path string) string {
+	return pathParam.ReplaceAllString(strings.TrimSuffix(path, "/"), "{}")
+}
+
+// Returns the names of the path parameters.
+func pathParams(path string) []string {
+	var names []string
+	for _, match := range pathParam.FindAllString(path, -1) {
+		names = append(names, strings.Trim(match, "{}"))
+	}
+	return names
+}
+
+func operationKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + normalizePath(path)
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type Client struct{}
+
+type StringList []string
+
+func (c *Client) endpoint(path string, query url.Values) string {
+	return path
+}
+
+func (c *Client) GenerateRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, url, body)
+}
+
+func (c *Client) invoke(ctx context.Context, op Operation, input any, req *http.Request, output any) error {
+	return nil
+}
@@ -0,0 +1,16 @@
+package client
+
+type Operation struct {
+	Name       string
+	Method     string
+	Path       string
+	Idempotent bool
+	Mutating   bool
+}
+
+var (
+	opGetRoles  = Operation{Name: "GetRoles", Method: "GET", Path: "/admin/roles", Idempotent: true}
+	opGetRole   = Operation{Name: "GetRole", Method: "GET", Path: "/admin/roles/{id}", Idempotent: true}
+	opPutRole   = Operation{Name: "PutRole", Method: "PUT", Path: "/admin/roles/{id}", Idempotent: true, Mutating: true}
+	opGetGroups = Operation{Name: "GetGroups", Method: "GET", Path: "/admin/groups", Idempotent: true}
+)
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+type Role struct {
+	Id    string     `json:"id"`
+	Name  string     `json:"name"`
+	Owner StringList `json:"owner"`
+
+	// Decoded from the attributes.
+	Attributes map[string]string `json:"attributes"`
+}
+
+type GetRolesInput struct {
+	Limit int `json:"limit"`
+}
+
+type GetRolesOutput struct {
+	Roles []Role `json:"roles"`
+}
+
+// Returns the roles.
+//
+//meta:operation GET /admin/roles
+func (c *Client) GetRoles(ctx context.Context, params GetRolesInput) (*GetRolesOutput, error) {
+	var output GetRolesOutput
+	req, err := c.GenerateRequest(ctx, "GET", c.endpoint("/admin/roles", url.Values{}), nil)
+	if err != nil {
+		return nil, err
+	}
+	err = c.invoke(ctx, opGetRoles, params, req, &output)
+	if err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+type GetRoleInput struct {
+	Id string `json:"id"`
+}
+
+// Returns the role of the id.
+//
+//meta:operation GET /admin/roles/{id}
+func (c *Client) GetRole(ctx context.Context, params GetRoleInput) (*Role, error) {
+	var output Role
+	req, err := c.GenerateRequest(ctx, "GET", c.endpoint("/admin/roles/"+url.PathEscape(params.Id), nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	err = c.invoke(ctx, opGetRole, params, req, &output)
+	if err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// Replaces the role of the id.
+//
+//meta:operation PUT /admin/roles/{id}
+func (c *Client) PutRole(ctx context.Context, params Role) (*Role, error) {
+	return nil, nil
+}
+
+// Returns the groups.
+//
+//meta:operation GET /admin/groups
+func (c *Client) GetGroups(ctx context.Context) ([]string, error) {
+	return nil, nil
+}